@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"go-core/opentracing/jaeger"
+
+	"github.com/go-redis/redis"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+// maxBloomFilterBits is the largest bitmap Redis can store in a single key (512MB)
+const maxBloomFilterBits uint64 = 1 << 32
+
+var (
+	// ErrBloomFilterUnsupported is returned when the helper does not expose a redis client
+	ErrBloomFilterUnsupported = errors.New("bloom filter is not supported by this cache helper")
+	// ErrBloomFilterInvalidOption is returned when capacity or false positive rate are out of range
+	ErrBloomFilterInvalidOption = errors.New("bloom filter capacity must be positive and false positive rate in (0, 1)")
+)
+
+type (
+	// BloomFilter is a probabilistic set stored in a redis bitmap
+	BloomFilter interface {
+		Add(ctx context.Context, items ...string) error
+		Exists(ctx context.Context, item string) (bool, error)
+		ExistsMulti(ctx context.Context, items ...string) ([]bool, error)
+		Clear(ctx context.Context) error
+		// Bits returns the size of the bitmap
+		Bits() uint64
+		// HashFunctions returns the number of bits set per item
+		HashFunctions() uint64
+	}
+
+	// BloomFilterOption represents bloom filter option
+	BloomFilterOption struct {
+		// Capacity is the expected number of items
+		Capacity uint64
+		// FalsePositiveRate is the acceptable false positive rate when Capacity items were added
+		FalsePositiveRate float64
+		// Expiration is refreshed on every Add, zero means the filter never expires
+		Expiration time.Duration
+	}
+
+	redisBloomFilter struct {
+		client     redis.Cmdable
+		key        string
+		bits       uint64
+		hashes     uint64
+		expiration time.Duration
+	}
+
+	cmdableProvider interface {
+		cmdable() redis.Cmdable
+	}
+)
+
+// NewBloomFilter creates a bloom filter stored at key. Every bit of the filter
+// lives in the same key so it works on both standalone and cluster helpers.
+func NewBloomFilter(helper CacheHelper, key string, opt BloomFilterOption) (BloomFilter, error) {
+	provider, ok := helper.(cmdableProvider)
+	if !ok {
+		return nil, ErrBloomFilterUnsupported
+	}
+	if opt.Capacity == 0 || opt.FalsePositiveRate <= 0 || opt.FalsePositiveRate >= 1 {
+		return nil, ErrBloomFilterInvalidOption
+	}
+	bits, hashes := bloomFilterEstimate(opt.Capacity, opt.FalsePositiveRate)
+	return &redisBloomFilter{
+		client:     provider.cmdable(),
+		key:        key,
+		bits:       bits,
+		hashes:     hashes,
+		expiration: opt.Expiration,
+	}, nil
+}
+
+// bloomFilterEstimate returns the optimal bitmap size m and number of hash functions k
+// for n items at false positive rate p: m = -n*ln(p)/ln(2)^2, k = m/n*ln(2)
+func bloomFilterEstimate(n uint64, p float64) (uint64, uint64) {
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	bits := uint64(m)
+	if bits > maxBloomFilterBits {
+		bits = maxBloomFilterBits
+	}
+	if bits == 0 {
+		bits = 1
+	}
+	hashes := uint64(math.Round(float64(bits) / float64(n) * math.Ln2))
+	if hashes == 0 {
+		hashes = 1
+	}
+	return bits, hashes
+}
+
+// locations derives the k bit offsets of item using double hashing: h1 + i*h2
+func (f *redisBloomFilter) locations(item string) []int64 {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(item))
+	h1 := hasher.Sum64()
+	h2 := splitMix64(h1) | 1
+
+	offsets := make([]int64, f.hashes)
+	for i := uint64(0); i < f.hashes; i++ {
+		offsets[i] = int64((h1 + i*h2) % f.bits)
+	}
+	return offsets
+}
+
+func splitMix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+func (f *redisBloomFilter) Add(ctx context.Context, items ...string) (err error) {
+	span := jaeger.Start(ctx, ">helper.redisBloomFilter/Add", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	if len(items) == 0 {
+		return nil
+	}
+
+	pipeline := f.client.Pipeline()
+	defer pipeline.Close()
+	for _, item := range items {
+		for _, offset := range f.locations(item) {
+			pipeline.SetBit(f.key, offset, 1)
+		}
+	}
+	if f.expiration != time.Duration(0) {
+		pipeline.Expire(f.key, f.expiration)
+	}
+	_, err = pipeline.Exec()
+	return err
+}
+
+func (f *redisBloomFilter) Exists(ctx context.Context, item string) (isExisted bool, err error) {
+	span := jaeger.Start(ctx, ">helper.redisBloomFilter/Exists", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+
+	results, err := f.exists(item)
+	if err != nil {
+		return false, err
+	}
+	return results[0], nil
+}
+
+func (f *redisBloomFilter) ExistsMulti(ctx context.Context, items ...string) (results []bool, err error) {
+	span := jaeger.Start(ctx, ">helper.redisBloomFilter/ExistsMulti", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	if len(items) == 0 {
+		return []bool{}, nil
+	}
+	return f.exists(items...)
+}
+
+func (f *redisBloomFilter) exists(items ...string) ([]bool, error) {
+	pipeline := f.client.Pipeline()
+	defer pipeline.Close()
+
+	cmds := make([][]*redis.IntCmd, len(items))
+	for index, item := range items {
+		for _, offset := range f.locations(item) {
+			cmds[index] = append(cmds[index], pipeline.GetBit(f.key, offset))
+		}
+	}
+	if _, err := pipeline.Exec(); err != nil {
+		return nil, err
+	}
+
+	results := make([]bool, len(items))
+	for index, itemCmds := range cmds {
+		results[index] = true
+		for _, cmd := range itemCmds {
+			if cmd.Val() == 0 {
+				results[index] = false
+				break
+			}
+		}
+	}
+	return results, nil
+}
+
+func (f *redisBloomFilter) Clear(ctx context.Context) (err error) {
+	span := jaeger.Start(ctx, ">helper.redisBloomFilter/Clear", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	return f.client.Del(f.key).Err()
+}
+
+func (f *redisBloomFilter) Bits() uint64 {
+	return f.bits
+}
+
+func (f *redisBloomFilter) HashFunctions() uint64 {
+	return f.hashes
+}
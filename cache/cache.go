@@ -10,12 +10,18 @@ import (
 	"go.uber.org/zap"
 )
 
+// Nil is returned when a key or a hash field does not exist
+const Nil = redis.Nil
+
 type CacheMessage struct {
 	redis.Message
 }
 
 type SubscribeFunc func(CacheMessage) error
 
+// ScanFunc is called once per key found by ScanKeys, returning an error stops the scan
+type ScanFunc func(key string) error
+
 // CacheHelper is helper of Cache
 type CacheHelper interface {
 	Exists(ctx context.Context, key string) error
@@ -26,6 +32,7 @@ type CacheHelper interface {
 	Expire(ctx context.Context, key string, expiration time.Duration) error
 	DelMulti(ctx context.Context, keys ...string) error
 	GetKeysByPattern(ctx context.Context, pattern string, cursor uint64, limit int64) ([]string, uint64, error)
+	ScanKeys(ctx context.Context, pattern string, count int64, scanFunc ScanFunc) error
 	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
 	SubscribeMessage(ctx context.Context, keySpace string, subscribeFunc SubscribeFunc)
 	PublishMessage(ctx context.Context, keySpace string, message interface{}) error
@@ -35,7 +42,10 @@ type CacheHelper interface {
 	GetStrLenght(ctx context.Context, key string) (int64, error)
 	GetType(ctx context.Context, key string) (string, error)
 	DebugObjectByKey(ctx context.Context, key string) (string, error)
-	TimeExpire(ctx context.Context, key string) (time.Duration, error) // return second
+	TimeExpire(ctx context.Context, key string) (time.Duration, error)  // return second
+	PTimeExpire(ctx context.Context, key string) (time.Duration, error) // return millisecond, -1ms without expiration, -2ms for a missing key
+	Dump(ctx context.Context, key string) (string, error)
+	Restore(ctx context.Context, key string, ttl time.Duration, value string, replace bool) error
 	HSet(ctx context.Context, key, mapKey string, mapValue interface{}, expiration time.Duration) (bool, error)
 	HSetNX(ctx context.Context, key string, mapKey string, mapValue interface{}, expiration time.Duration) (bool, error)
 	HGet(ctx context.Context, key, mapKey string) (string, error)
@@ -43,6 +53,19 @@ type CacheHelper interface {
 	HIncreaseBy(ctx context.Context, key, mapKey string, increase int64) (bool, string, error)
 	HMSet(ctx context.Context, key string, mapData map[string]interface{}, expiration time.Duration) (bool, error)
 	HMGet(ctx context.Context, key string, fields []string) (map[string]interface{}, error)
+	HDel(ctx context.Context, key string, fields ...string) (int64, error)
+	HLen(ctx context.Context, key string) (int64, error)
+	// HyperLogLog
+	PFAdd(ctx context.Context, key string, elements ...interface{}) (bool, error)
+	PFCount(ctx context.Context, keys ...string) (int64, error)
+	PFMerge(ctx context.Context, destKey string, sourceKeys ...string) error
+	// StreamAdd appends an entry to a stream trimmed to about maxLen entries (zero keeps every entry)
+	// and returns its ID
+	StreamAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error)
+	// HotKeys reports the most accessed keys when hot key sampling is enabled
+	HotKeys() []HotKey
+	// HealthCheck pings the server and reports pool saturation
+	HealthCheck(ctx context.Context) error
 }
 type CacheHelperEnhancement interface {
 	CacheHelper
@@ -227,13 +250,19 @@ type CacheOption struct {
 
 // NewCacheHelper creates an instance
 func NewCacheHelper(addrs []string, opts ...CacheOption) CacheHelper {
+	monitor := newCommandMonitor(opts)
 	if len(addrs) > 1 {
 		clusterClient, err := initRedisCluster(addrs)
 		if err != nil {
 			zap.S().Panic("Failed to init redis cluster", zap.Error(err))
 		}
+		if monitor != nil {
+			clusterClient.WrapProcess(monitor.wrapProcess)
+			clusterClient.WrapProcessPipeline(monitor.wrapProcessPipeline)
+		}
 		return &clusterRedisHelper{
 			clusterClient: clusterClient,
+			monitor:       monitor,
 		}
 	}
 	// get db config
@@ -247,7 +276,12 @@ func NewCacheHelper(addrs []string, opts ...CacheOption) CacheHelper {
 	if err != nil {
 		zap.S().Panic("Failed to init redis", zap.Error(err))
 	}
+	if monitor != nil {
+		client.WrapProcess(monitor.wrapProcess)
+		client.WrapProcessPipeline(monitor.wrapProcessPipeline)
+	}
 	return &redisHelper{
-		client: client,
+		client:  client,
+		monitor: monitor,
 	}
 }
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// hashFieldValue keeps strings as they are and encodes everything else as JSON
+func hashFieldValue(value interface{}) (string, error) {
+	if stringValue, isString := value.(string); isString {
+		return stringValue, nil
+	}
+	marshalValue, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(marshalValue), nil
+}
+
+func hSet(client redis.Cmdable, key, mapKey string, mapValue interface{}, expiration time.Duration) (bool, error) {
+	value, err := hashFieldValue(mapValue)
+	if err != nil {
+		return false, err
+	}
+	isSet, err := client.HSet(key, mapKey, value).Result()
+	if !isSet || err != nil {
+		return isSet, err
+	}
+	if expiration != time.Duration(0) {
+		return client.Expire(key, expiration).Result()
+	}
+	return true, nil
+}
+
+func hSetNX(client redis.Cmdable, key, mapKey string, mapValue interface{}, expiration time.Duration) (bool, error) {
+	value, err := hashFieldValue(mapValue)
+	if err != nil {
+		return false, err
+	}
+	isSet, err := client.HSetNX(key, mapKey, value).Result()
+	if !isSet || err != nil {
+		return isSet, err
+	}
+	if expiration != time.Duration(0) {
+		return client.Expire(key, expiration).Result()
+	}
+	return true, nil
+}
+
+// hGet returns an empty value for a missing key or field, only the client errors are returned
+func hGet(client redis.Cmdable, key, mapKey string) (string, error) {
+	value, err := client.HGet(key, mapKey).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return value, err
+}
+
+func hGetAll(client redis.Cmdable, key string) (map[string]string, error) {
+	return client.HGetAll(key).Result()
+}
+
+func hIncreaseBy(client redis.Cmdable, key, mapKey string, increase int64) (bool, string, error) {
+	valueInt, err := client.HIncrBy(key, mapKey, increase).Result()
+	if err != nil {
+		return false, "", err
+	}
+	return true, strconv.FormatInt(valueInt, 10), nil
+}
+
+func hMSet(client redis.Cmdable, key string, mapData map[string]interface{}, expiration time.Duration) (bool, error) {
+	inputData := make(map[string]interface{}, len(mapData))
+	for mapKey, mapValue := range mapData {
+		value, err := hashFieldValue(mapValue)
+		if err != nil {
+			return false, err
+		}
+		inputData[mapKey] = value
+	}
+	if ok, err := client.HMSet(key, inputData).Result(); ok != "OK" || err != nil {
+		return false, err
+	}
+	if expiration != time.Duration(0) {
+		return client.Expire(key, expiration).Result()
+	}
+	return true, nil
+}
+
+func hMGet(client redis.Cmdable, key string, fields []string) (map[string]interface{}, error) {
+	results, err := client.HMGet(key, fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{}, len(results))
+	for index, item := range fields {
+		result[item] = results[index]
+	}
+	return result, nil
+}
+
+func hDel(client redis.Cmdable, key string, fields ...string) (int64, error) {
+	return client.HDel(key, fields...).Result()
+}
+
+func hLen(client redis.Cmdable, key string) (int64, error) {
+	return client.HLen(key).Result()
+}
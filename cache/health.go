@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go-core/opentracing/jaeger"
+
+	"github.com/go-redis/redis"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+const defaultHealthCheckTimeout = time.Second
+
+// PoolSaturatedError is returned by HealthCheck when commands timed out waiting for a free connection
+type PoolSaturatedError struct {
+	Timeouts   uint32
+	TotalConns uint32
+	IdleConns  uint32
+}
+
+func (e *PoolSaturatedError) Error() string {
+	return fmt.Sprintf("connection pool saturated: %d wait timeouts since the last check, %d connections, %d idle",
+		e.Timeouts, e.TotalConns, e.IdleConns)
+}
+
+// healthCheck runs ping within ctx, default 1s, then compares the pool timeouts with the previous check
+func healthCheck(ctx context.Context, ping func() error, poolStats func() *redis.PoolStats, lastTimeouts *uint32) error {
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultHealthCheckTimeout)
+		defer cancel()
+	}
+	// go-redis v6 does not bound commands by the context, the ping is abandoned on timeout
+	result := make(chan error, 1)
+	go func() {
+		result <- ping()
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-result:
+		if err != nil {
+			return err
+		}
+	}
+
+	stats := poolStats()
+	if timeouts := stats.Timeouts - atomic.SwapUint32(lastTimeouts, stats.Timeouts); timeouts > 0 {
+		return &PoolSaturatedError{Timeouts: timeouts, TotalConns: stats.TotalConns, IdleConns: stats.IdleConns}
+	}
+	return nil
+}
+
+// HealthCheck pings the server and fails with *PoolSaturatedError when commands timed out waiting for a connection
+func (h *redisHelper) HealthCheck(ctx context.Context) (err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/HealthCheck", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	return healthCheck(ctx, func() error {
+		return h.client.Ping().Err()
+	}, h.client.PoolStats, &h.lastPoolTimeouts)
+}
+
+// HealthCheck pings every master node and fails with *PoolSaturatedError when commands timed out waiting for a connection
+func (h *clusterRedisHelper) HealthCheck(ctx context.Context) (err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/HealthCheck", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	return healthCheck(ctx, func() error {
+		return h.clusterClient.ForEachMaster(func(client *redis.Client) error {
+			return client.Ping().Err()
+		})
+	}, h.clusterClient.PoolStats, &h.lastPoolTimeouts)
+}
@@ -0,0 +1,244 @@
+package cache
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "go-core/log"
+
+	"github.com/go-redis/redis"
+)
+
+const (
+	countMinSketchDepth = 4
+	countMinSketchWidth = 2048
+
+	defaultHotKeyTop    = 20
+	defaultHotKeyWindow = time.Minute
+)
+
+// commands whose first argument is not a key
+var noKeyCommands = map[string]bool{
+	"auth": true, "client": true, "cluster": true, "command": true, "config": true,
+	"dbsize": true, "echo": true, "flushall": true, "flushdb": true, "info": true,
+	"memory": true, "object": true, "ping": true, "psubscribe": true, "publish": true,
+	"punsubscribe": true, "readonly": true, "scan": true, "script": true, "select": true,
+	"subscribe": true, "time": true, "unsubscribe": true, "debug": true, "eval": true, "evalsha": true,
+}
+
+type (
+	// HotKey represents the estimated number of accesses of a key
+	HotKey struct {
+		Key   string
+		Count uint64
+	}
+
+	// slowCommand is logged with log.Object so key names follow the masking rules
+	slowCommand struct {
+		Command  string `json:"command"`
+		Key      string `json:"key"`
+		Keys     int    `json:"keys,omitempty"`
+		Duration string `json:"duration"`
+		Error    string `json:"error,omitempty"`
+	}
+
+	countMinSketch struct {
+		counters [countMinSketchDepth][countMinSketchWidth]uint32
+	}
+
+	// commandMonitor samples key accesses into a count-min sketch and keeps the top K keys
+	commandMonitor struct {
+		sampleRate    float64
+		slowThreshold time.Duration
+		top           int
+		window        time.Duration
+
+		mutex       sync.Mutex
+		random      *rand.Rand
+		sketch      countMinSketch
+		candidates  map[string]uint64
+		windowStart time.Time
+	}
+)
+
+// newCommandMonitor reads the monitoring options, it returns nil when neither sampling nor slow logging is enabled.
+//
+//	hot_key_sample_rate    float64 in (0, 1], fraction of commands sampled
+//	hot_key_top            int, number of keys reported by HotKeys
+//	hot_key_window         time.Duration, counts are halved after each window
+//	slow_command_threshold time.Duration, commands slower than this are logged
+func newCommandMonitor(opts []CacheOption) *commandMonitor {
+	monitor := &commandMonitor{
+		top:    defaultHotKeyTop,
+		window: defaultHotKeyWindow,
+	}
+	for _, item := range opts {
+		switch item.Key {
+		case "hot_key_sample_rate":
+			monitor.sampleRate = item.Value.(float64)
+		case "hot_key_top":
+			monitor.top = item.Value.(int)
+		case "hot_key_window":
+			monitor.window = item.Value.(time.Duration)
+		case "slow_command_threshold":
+			monitor.slowThreshold = item.Value.(time.Duration)
+		}
+	}
+	if monitor.sampleRate <= 0 && monitor.slowThreshold <= 0 {
+		return nil
+	}
+	if monitor.sampleRate > 1 {
+		monitor.sampleRate = 1
+	}
+	monitor.random = rand.New(rand.NewSource(time.Now().UnixNano()))
+	monitor.candidates = make(map[string]uint64, monitor.top+1)
+	monitor.windowStart = time.Now()
+	return monitor
+}
+
+func (m *commandMonitor) wrapProcess(oldProcess func(cmd redis.Cmder) error) func(cmd redis.Cmder) error {
+	return func(cmd redis.Cmder) error {
+		start := time.Now()
+		err := oldProcess(cmd)
+		duration := time.Since(start)
+
+		key := commandKey(cmd)
+		m.sample(key)
+		if m.slowThreshold > 0 && duration >= m.slowThreshold {
+			m.logSlow(slowCommand{Command: cmd.Name(), Key: key, Duration: duration.String()}, err)
+		}
+		return err
+	}
+}
+
+func (m *commandMonitor) wrapProcessPipeline(oldProcess func(cmds []redis.Cmder) error) func(cmds []redis.Cmder) error {
+	return func(cmds []redis.Cmder) error {
+		start := time.Now()
+		err := oldProcess(cmds)
+		duration := time.Since(start)
+
+		for _, cmd := range cmds {
+			m.sample(commandKey(cmd))
+		}
+		if m.slowThreshold > 0 && duration >= m.slowThreshold {
+			entry := slowCommand{Command: "pipeline", Keys: len(cmds), Duration: duration.String()}
+			if len(cmds) > 0 {
+				entry.Key = commandKey(cmds[0])
+			}
+			m.logSlow(entry, err)
+		}
+		return err
+	}
+}
+
+func (m *commandMonitor) logSlow(entry slowCommand, err error) {
+	if err != nil && err != redis.Nil {
+		entry.Error = err.Error()
+	}
+	log.Logger.L.Warn("Slow cache command", log.Object("command", entry))
+}
+
+func commandKey(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) < 2 || noKeyCommands[strings.ToLower(cmd.Name())] {
+		return ""
+	}
+	key, _ := args[1].(string)
+	return key
+}
+
+func (m *commandMonitor) sample(key string) {
+	if key == "" || m.sampleRate <= 0 {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.random.Float64() >= m.sampleRate {
+		return
+	}
+	m.decay()
+
+	estimate := m.sketch.add(key)
+	if _, ok := m.candidates[key]; ok || len(m.candidates) < m.top {
+		m.candidates[key] = estimate
+		return
+	}
+	// replace the coldest candidate when the key became hotter
+	var (
+		coldestKey   string
+		coldestCount uint64
+		first        = true
+	)
+	for candidate, count := range m.candidates {
+		if first || count < coldestCount {
+			coldestKey, coldestCount, first = candidate, count, false
+		}
+	}
+	if estimate > coldestCount {
+		delete(m.candidates, coldestKey)
+		m.candidates[key] = estimate
+	}
+}
+
+// decay halves every count once per window so the report favours recent traffic
+func (m *commandMonitor) decay() {
+	if m.window <= 0 || time.Since(m.windowStart) < m.window {
+		return
+	}
+	m.windowStart = time.Now()
+	m.sketch.halve()
+	for key, count := range m.candidates {
+		if count /= 2; count == 0 {
+			delete(m.candidates, key)
+			continue
+		}
+		m.candidates[key] = count
+	}
+}
+
+// hotKeys returns the candidates sorted by estimated accesses, scaled by the sample rate
+func (m *commandMonitor) hotKeys() []HotKey {
+	if m == nil || m.sampleRate <= 0 {
+		return nil
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	result := make([]HotKey, 0, len(m.candidates))
+	for key, count := range m.candidates {
+		result = append(result, HotKey{Key: key, Count: uint64(float64(count) / m.sampleRate)})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Count > result[j].Count
+	})
+	return result
+}
+
+// add increments key and returns its estimated count
+func (s *countMinSketch) add(key string) uint64 {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(key))
+	hash := hasher.Sum64()
+
+	var estimate uint32
+	for row := 0; row < countMinSketchDepth; row++ {
+		hash = splitMix64(hash)
+		column := hash % countMinSketchWidth
+		s.counters[row][column]++
+		if row == 0 || s.counters[row][column] < estimate {
+			estimate = s.counters[row][column]
+		}
+	}
+	return uint64(estimate)
+}
+
+func (s *countMinSketch) halve() {
+	for row := range s.counters {
+		for column := range s.counters[row] {
+			s.counters[row][column] /= 2
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sync"
 	"time"
 
 	"go-core/opentracing/jaeger"
@@ -23,6 +24,9 @@ func initRedisCluster(addrs []string) (*redis.ClusterClient, error) {
 
 type clusterRedisHelper struct {
 	clusterClient *redis.ClusterClient
+	monitor       *commandMonitor
+
+	lastPoolTimeouts uint32
 }
 
 func (h *clusterRedisHelper) GetTransaction(ctx context.Context, transactionID string) CacheTransactionExecution {
@@ -182,6 +186,13 @@ func (h *clusterRedisHelper) DelMulti(ctx context.Context, keys ...string) error
 	defer func() {
 		jaeger.Finish(span, err)
 	}()
+	// keys may live in different slots, so delete them one by one in a pipeline
+	pipeline := h.clusterClient.Pipeline()
+	defer pipeline.Close()
+	for _, key := range keys {
+		pipeline.Del(key)
+	}
+	_, err = pipeline.Exec()
 	return err
 }
 
@@ -264,26 +275,180 @@ func (h *clusterRedisHelper) TimeExpire(ctx context.Context, key string) (time.D
 	return h.clusterClient.TTL(key).Result()
 }
 
+func (h *clusterRedisHelper) PTimeExpire(ctx context.Context, key string) (ttl time.Duration, err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/PTimeExpire", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	ttl, err = h.clusterClient.PTTL(key).Result()
+	return ttl, err
+}
+
 func (h *clusterRedisHelper) HSet(ctx context.Context, key, mapKey string, mapValue interface{}, expiration time.Duration) (isSet bool, err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/HSet", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	isSet, err = hSet(h.clusterClient, key, mapKey, mapValue, expiration)
 	return isSet, err
 }
+
 func (h *clusterRedisHelper) HSetNX(ctx context.Context, key string, mapKey string, mapValue interface{}, expiration time.Duration) (isSet bool, err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/HSetNX", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	isSet, err = hSetNX(h.clusterClient, key, mapKey, mapValue, expiration)
 	return isSet, err
 }
+
 func (h *clusterRedisHelper) HGet(ctx context.Context, key, mapKey string) (value string, err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/HGet", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	value, err = hGet(h.clusterClient, key, mapKey)
 	return value, err
 }
+
 func (h *clusterRedisHelper) HGetAll(ctx context.Context, key string, mapKeys []string) (values map[string]string, err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/HGetAll", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	values, err = hGetAll(h.clusterClient, key)
 	return values, err
 }
+
 func (h *clusterRedisHelper) HIncreaseBy(ctx context.Context, key, mapKey string, increase int64) (isIncreased bool, value string, err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/HIncreaseBy", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	isIncreased, value, err = hIncreaseBy(h.clusterClient, key, mapKey, increase)
 	return isIncreased, value, err
 }
 
 func (h *clusterRedisHelper) HMSet(ctx context.Context, key string, mapData map[string]interface{}, expiration time.Duration) (isSet bool, err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/HMSet", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	isSet, err = hMSet(h.clusterClient, key, mapData, expiration)
 	return isSet, err
 }
 
 func (h *clusterRedisHelper) HMGet(ctx context.Context, key string, fields []string) (result map[string]interface{}, err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/HMGet", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	result, err = hMGet(h.clusterClient, key, fields)
 	return result, err
 }
+
+func (h *clusterRedisHelper) HDel(ctx context.Context, key string, fields ...string) (deleted int64, err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/HDel", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	deleted, err = hDel(h.clusterClient, key, fields...)
+	return deleted, err
+}
+
+func (h *clusterRedisHelper) HLen(ctx context.Context, key string) (length int64, err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/HLen", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	length, err = hLen(h.clusterClient, key)
+	return length, err
+}
+
+func (h *clusterRedisHelper) cmdable() redis.Cmdable {
+	return h.clusterClient
+}
+
+// PFAdd adds elements to a HyperLogLog
+func (h *clusterRedisHelper) PFAdd(ctx context.Context, key string, elements ...interface{}) (isChanged bool, err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/PFAdd", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+
+	changed, err := h.clusterClient.PFAdd(key, elements...).Result()
+	if err != nil {
+		return false, err
+	}
+	return changed == 1, nil
+}
+
+// PFCount counts the union of HyperLogLogs, keys must hash to the same slot (use {hash tags})
+func (h *clusterRedisHelper) PFCount(ctx context.Context, keys ...string) (count int64, err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/PFCount", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	return h.clusterClient.PFCount(keys...).Result()
+}
+
+// PFMerge merges HyperLogLogs into destKey, keys must hash to the same slot (use {hash tags})
+func (h *clusterRedisHelper) PFMerge(ctx context.Context, destKey string, sourceKeys ...string) (err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/PFMerge", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	return h.clusterClient.PFMerge(destKey, sourceKeys...).Err()
+}
+
+// ScanKeys scans every master node of the cluster, scanFunc is never called concurrently
+func (h *clusterRedisHelper) ScanKeys(ctx context.Context, pattern string, count int64, scanFunc ScanFunc) (err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/ScanKeys", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+
+	var mutex sync.Mutex
+	return h.clusterClient.ForEachMaster(func(client *redis.Client) error {
+		return scanClient(ctx, client, pattern, count, func(key string) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return scanFunc(key)
+		})
+	})
+}
+
+func (h *clusterRedisHelper) Dump(ctx context.Context, key string) (value string, err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/Dump", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	return h.clusterClient.Dump(key).Result()
+}
+
+func (h *clusterRedisHelper) Restore(ctx context.Context, key string, ttl time.Duration, value string, replace bool) (err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/Restore", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	if replace {
+		return h.clusterClient.RestoreReplace(key, ttl, value).Err()
+	}
+	return h.clusterClient.Restore(key, ttl, value).Err()
+}
+
+func (h *clusterRedisHelper) StreamAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (id string, err error) {
+	span := jaeger.Start(ctx, ">helper.clusterRedisHelper/StreamAdd", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	return h.clusterClient.XAdd(&redis.XAddArgs{
+		Stream:       stream,
+		MaxLenApprox: maxLen,
+		Values:       values,
+	}).Result()
+}
+
+func (h *clusterRedisHelper) HotKeys() []HotKey {
+	return h.monitor.hotKeys()
+}
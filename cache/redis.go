@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-	"strconv"
 	"time"
 
 	"go-core/opentracing/jaeger"
@@ -16,7 +15,10 @@ import (
 )
 
 type redisHelper struct {
-	client *redis.Client
+	client  *redis.Client
+	monitor *commandMonitor
+
+	lastPoolTimeouts uint32
 }
 
 func initRedis(addr string, db int) (*redis.Client, error) {
@@ -310,154 +312,191 @@ func (h *redisHelper) TimeExpire(ctx context.Context, key string) (time.Duration
 	return h.client.TTL(key).Result()
 }
 
-func (h *redisHelper) HSet(ctx context.Context, key, mapKey string, mapValue interface{}, expiration time.Duration) (isSet bool, err error) {
+func (h *redisHelper) PTimeExpire(ctx context.Context, key string) (ttl time.Duration, err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/PTimeExpire", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	ttl, err = h.client.PTTL(key).Result()
+	return ttl, err
+}
 
-	var (
-		marshalValue []byte
-		result       *redis.BoolCmd
-	)
-	if _, isString := mapValue.(string); !isString {
-		marshalValue, err = json.Marshal(mapValue)
-		if err != nil {
-			return isSet, err
-		}
-	}
+func (h *redisHelper) HSet(ctx context.Context, key, mapKey string, mapValue interface{}, expiration time.Duration) (isSet bool, err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/HSet", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	isSet, err = hSet(h.client, key, mapKey, mapValue, expiration)
+	return isSet, err
+}
 
-	result = h.client.HSet(key, mapKey, string(marshalValue))
-	if result.Err() != nil {
-		return isSet, err
-	}
-	if isSet, err = result.Result(); !isSet || err != nil {
-		return isSet, err
-	}
+func (h *redisHelper) HSetNX(ctx context.Context, key string, mapKey string, mapValue interface{}, expiration time.Duration) (isSet bool, err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/HSetNX", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	isSet, err = hSetNX(h.client, key, mapKey, mapValue, expiration)
+	return isSet, err
+}
 
-	if expiration != time.Duration(0) {
-		result = h.client.Expire(key, expiration)
-	}
+func (h *redisHelper) HGet(ctx context.Context, key, mapKey string) (value string, err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/HGet", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	value, err = hGet(h.client, key, mapKey)
+	return value, err
+}
 
-	if isSet, err = result.Result(); !isSet || err != nil {
-		return isSet, err
-	}
-	return true, nil
+func (h *redisHelper) HGetAll(ctx context.Context, key string, mapKeys []string) (values map[string]string, err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/HGetAll", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	values, err = hGetAll(h.client, key)
+	return values, err
 }
-func (h *redisHelper) HSetNX(ctx context.Context, key string, mapKey string, mapValue interface{}, expiration time.Duration) (isSet bool, err error) {
 
-	var (
-		marshalValue []byte
-		boolResult   *redis.BoolCmd
-	)
-	if _, isString := mapValue.(string); !isString {
-		marshalValue, err = json.Marshal(mapValue)
-		if err != nil {
-			return isSet, err
-		}
-	}
+func (h *redisHelper) HIncreaseBy(ctx context.Context, key, mapKey string, increase int64) (isIncreased bool, value string, err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/HIncreaseBy", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	isIncreased, value, err = hIncreaseBy(h.client, key, mapKey, increase)
+	return isIncreased, value, err
+}
 
-	boolResult = h.client.HSetNX(key, mapKey, string(marshalValue))
-	if isSet, err = boolResult.Result(); !isSet || err != nil {
-		return isSet, err
-	}
-	if expiration != time.Duration(0) {
-		boolResult = h.client.Expire(key, expiration)
-	}
-	if isSet, err = boolResult.Result(); !isSet || err != nil {
-		return isSet, err
-	}
+func (h *redisHelper) HMSet(ctx context.Context, key string, mapData map[string]interface{}, expiration time.Duration) (isSet bool, err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/HMSet", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	isSet, err = hMSet(h.client, key, mapData, expiration)
 	return isSet, err
 }
-func (h *redisHelper) HGet(ctx context.Context, key, mapKey string) (value string, err error) {
 
-	result := h.client.HGet(key, mapKey)
-	if result.Err() != nil {
-		return value, err
-	}
-	if value, err = result.Result(); err != nil {
-		return value, err
-	}
-	return value, nil
+func (h *redisHelper) HMGet(ctx context.Context, key string, fields []string) (result map[string]interface{}, err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/HMGet", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	result, err = hMGet(h.client, key, fields)
+	return result, err
 }
-func (h *redisHelper) HGetAll(ctx context.Context, key string, mapKeys []string) (values map[string]string, err error) {
 
-	result := h.client.HGetAll(key)
-	if result.Err() != nil {
-		return values, err
-	}
-	if values, err = result.Result(); values == nil || err != nil {
-		return values, err
-	}
-	return values, nil
+func (h *redisHelper) HDel(ctx context.Context, key string, fields ...string) (deleted int64, err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/HDel", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	deleted, err = hDel(h.client, key, fields...)
+	return deleted, err
 }
-func (h *redisHelper) HIncreaseBy(ctx context.Context, key, mapKey string, increase int64) (isIncreased bool, value string, err error) {
 
-	result := h.client.HIncrBy(key, mapKey, increase)
-	if result.Err() != nil {
-		return isIncreased, value, err
-	}
-	var (
-		valueInt int64
-	)
-	if valueInt, err = result.Result(); err != nil {
-		return isIncreased, value, err
+func (h *redisHelper) HLen(ctx context.Context, key string) (length int64, err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/HLen", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	length, err = hLen(h.client, key)
+	return length, err
+}
+
+func (h *redisHelper) cmdable() redis.Cmdable {
+	return h.client
+}
+
+func (h *redisHelper) PFAdd(ctx context.Context, key string, elements ...interface{}) (isChanged bool, err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/PFAdd", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+
+	changed, err := h.client.PFAdd(key, elements...).Result()
+	if err != nil {
+		return false, err
 	}
+	return changed == 1, nil
+}
 
-	return true, strconv.FormatInt(valueInt, 10), nil
+func (h *redisHelper) PFCount(ctx context.Context, keys ...string) (count int64, err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/PFCount", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	return h.client.PFCount(keys...).Result()
 }
 
-func (h *redisHelper) HMSet(ctx context.Context, key string, mapData map[string]interface{}, expiration time.Duration) (isSet bool, err error) {
+func (h *redisHelper) PFMerge(ctx context.Context, destKey string, sourceKeys ...string) (err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/PFMerge", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	return h.client.PFMerge(destKey, sourceKeys...).Err()
+}
 
-	var (
-		inputData    map[string]interface{} = make(map[string]interface{}, len(mapData))
-		marshalValue []byte
-		stringValue  string
-		isString     bool
-	)
+func (h *redisHelper) ScanKeys(ctx context.Context, pattern string, count int64, scanFunc ScanFunc) (err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/ScanKeys", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	return scanClient(ctx, h.client, pattern, count, scanFunc)
+}
 
-	for key, value := range mapData {
-		if stringValue, isString = value.(string); !isString {
-			marshalValue, err = json.Marshal(value)
-			if err != nil {
-				return isSet, err
+// scanClient iterates every key matching pattern on a single node
+func scanClient(ctx context.Context, client *redis.Client, pattern string, count int64, scanFunc ScanFunc) error {
+	var cursor uint64
+	for {
+		keys, nextCursor, err := client.Scan(cursor, pattern, count).Result()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := scanFunc(key); err != nil {
+				return err
 			}
 		}
-		if stringValue == "" {
-			stringValue = string(marshalValue)
+		if nextCursor == 0 {
+			return nil
 		}
-		inputData[key] = string(stringValue)
-	}
-	result := h.client.HMSet(key, inputData)
-	if result.Err() != nil {
-		return isSet, err
-	}
-	if ok, err := result.Result(); ok != "OK" || err != nil {
-		return isSet, err
-	}
-	if expiration != time.Duration(0) {
-		boolResult := h.client.Expire(key, expiration)
-		if isSet, err = boolResult.Result(); !isSet || err != nil {
-			return isSet, err
+		if err := ctx.Err(); err != nil {
+			return err
 		}
+		cursor = nextCursor
 	}
-
-	return true, nil
 }
 
-func (h *redisHelper) HMGet(ctx context.Context, key string, fields []string) (result map[string]interface{}, err error) {
+func (h *redisHelper) Dump(ctx context.Context, key string) (value string, err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/Dump", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	return h.client.Dump(key).Result()
+}
 
-	var (
-		results []interface{}
-	)
-	sliceResult := h.client.HMGet(key, fields...)
-	if sliceResult.Err() != nil {
-		return result, err
+func (h *redisHelper) Restore(ctx context.Context, key string, ttl time.Duration, value string, replace bool) (err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/Restore", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	if replace {
+		return h.client.RestoreReplace(key, ttl, value).Err()
 	}
+	return h.client.Restore(key, ttl, value).Err()
+}
 
-	if results, err = sliceResult.Result(); err != nil {
-		return result, err
-	}
+func (h *redisHelper) StreamAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (id string, err error) {
+	span := jaeger.Start(ctx, ">helper.redisHelper/StreamAdd", ext.SpanKindRPCClient)
+	defer func() {
+		jaeger.Finish(span, err)
+	}()
+	return h.client.XAdd(&redis.XAddArgs{
+		Stream:       stream,
+		MaxLenApprox: maxLen,
+		Values:       values,
+	}).Result()
+}
 
-	result = make(map[string]interface{}, len(results))
-	for index, item := range fields {
-		result[item] = results[index]
-	}
-	return result, nil
+func (h *redisHelper) HotKeys() []HotKey {
+	return h.monitor.hotKeys()
 }
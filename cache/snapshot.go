@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultSnapshotScanCount = 1000
+
+// SnapshotEntry is one line of a snapshot file. Value is the base64 encoded
+// DUMP payload so the entry can be restored on any redis with the same or a newer RDB version.
+// TTL is the remaining time to live in milliseconds, zero for a key without expiration.
+type SnapshotEntry struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	TTL   int64  `json:"ttl_ms"`
+	Value string `json:"value"`
+}
+
+// SnapshotImportOption represents snapshot import option
+type SnapshotImportOption struct {
+	// Replace overwrites existing keys, otherwise existing keys are skipped
+	Replace bool
+	// DryRun decodes the snapshot without writing anything
+	DryRun bool
+	// Output receives the key of every entry a dry run would restore, one per line
+	Output io.Writer
+}
+
+// SnapshotResult reports the outcome of an export or import
+type SnapshotResult struct {
+	Keys    int
+	Skipped int
+}
+
+// ExportSnapshot writes every key matching pattern with its value and remaining TTL as JSON lines,
+// count is the SCAN count hint, default 1000
+func ExportSnapshot(ctx context.Context, helper CacheHelper, pattern string, count int64, writer io.Writer) (result SnapshotResult, err error) {
+	if count <= 0 {
+		count = defaultSnapshotScanCount
+	}
+	buffered := bufio.NewWriter(writer)
+	encoder := json.NewEncoder(buffered)
+
+	err = helper.ScanKeys(ctx, pattern, count, func(key string) error {
+		entry, err := snapshotEntry(ctx, helper, key)
+		if err != nil {
+			return err
+		}
+		// the key expired or was deleted while scanning
+		if entry == nil {
+			result.Skipped++
+			return nil
+		}
+		result.Keys++
+		return encoder.Encode(entry)
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, buffered.Flush()
+}
+
+// ExportSnapshotFile writes the snapshot of keys matching pattern to path
+func ExportSnapshotFile(ctx context.Context, helper CacheHelper, pattern string, count int64, path string) (SnapshotResult, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return SnapshotResult{}, err
+	}
+	result, err := ExportSnapshot(ctx, helper, pattern, count, file)
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	return result, err
+}
+
+func snapshotEntry(ctx context.Context, helper CacheHelper, key string) (*SnapshotEntry, error) {
+	keyType, err := helper.GetType(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if keyType == "none" {
+		return nil, nil
+	}
+	ttl, err := helper.PTimeExpire(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	// -1ms means no expiration, the key expired or was deleted otherwise
+	if ttl != -time.Millisecond && ttl <= 0 {
+		return nil, nil
+	}
+	value, err := helper.Dump(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	entry := &SnapshotEntry{
+		Key:   key,
+		Type:  keyType,
+		Value: base64.StdEncoding.EncodeToString([]byte(value)),
+	}
+	if ttl > 0 {
+		entry.TTL = ttl.Milliseconds()
+	}
+	return entry, nil
+}
+
+// ImportSnapshot restores the entries written by ExportSnapshot, TTLs restart from the import time
+func ImportSnapshot(ctx context.Context, helper CacheHelper, reader io.Reader, opt SnapshotImportOption) (result SnapshotResult, err error) {
+	decoder := json.NewDecoder(bufio.NewReader(reader))
+	for {
+		var entry SnapshotEntry
+		if err = decoder.Decode(&entry); err != nil {
+			if errors.Is(err, io.EOF) {
+				return result, nil
+			}
+			return result, err
+		}
+		value, err := base64.StdEncoding.DecodeString(entry.Value)
+		if err != nil {
+			return result, fmt.Errorf("decode %s: %w", entry.Key, err)
+		}
+		if opt.DryRun {
+			if opt.Output != nil {
+				fmt.Fprintln(opt.Output, entry.Key)
+			}
+			result.Keys++
+			continue
+		}
+		ttl := time.Duration(entry.TTL) * time.Millisecond
+		if err = helper.Restore(ctx, entry.Key, ttl, string(value), opt.Replace); err != nil {
+			if !opt.Replace && strings.HasPrefix(err.Error(), "BUSYKEY") {
+				result.Skipped++
+				continue
+			}
+			return result, fmt.Errorf("restore %s: %w", entry.Key, err)
+		}
+		result.Keys++
+	}
+}
+
+// ImportSnapshotFile restores the snapshot stored at path
+func ImportSnapshotFile(ctx context.Context, helper CacheHelper, path string, opt SnapshotImportOption) (SnapshotResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return SnapshotResult{}, err
+	}
+	defer file.Close()
+	return ImportSnapshot(ctx, helper, file, opt)
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "go-core/log"
+)
+
+// WarmUpFunc preloads keys into the cache
+type WarmUpFunc func(ctx context.Context, helper CacheHelper) error
+
+type warmUpHook struct {
+	name       string
+	warmUpFunc WarmUpFunc
+}
+
+var (
+	warmUpMutex sync.Mutex
+	warmUpHooks []warmUpHook
+	warmedUp    int32
+)
+
+// RegisterWarmUp registers a hook executed by WarmUp, hooks run in registration order
+func RegisterWarmUp(name string, warmUpFunc WarmUpFunc) {
+	warmUpMutex.Lock()
+	defer warmUpMutex.Unlock()
+	warmUpHooks = append(warmUpHooks, warmUpHook{name: name, warmUpFunc: warmUpFunc})
+}
+
+// WarmUp runs every registered hook and marks the cache as warmed up when all of them succeed.
+// Services should call it at startup before reporting ready.
+func WarmUp(ctx context.Context, helper CacheHelper) error {
+	warmUpMutex.Lock()
+	hooks := make([]warmUpHook, len(warmUpHooks))
+	copy(hooks, warmUpHooks)
+	warmUpMutex.Unlock()
+
+	for _, hook := range hooks {
+		start := time.Now()
+		if err := hook.warmUpFunc(ctx, helper); err != nil {
+			return fmt.Errorf("warm up %s: %w", hook.name, err)
+		}
+		log.Logger.Infow("Cache warmed up", "hook", hook.name, "duration", time.Since(start).String())
+	}
+	atomic.StoreInt32(&warmedUp, 1)
+	return nil
+}
+
+// IsWarmedUp reports whether WarmUp has completed successfully
+func IsWarmedUp() bool {
+	return atomic.LoadInt32(&warmedUp) == 1
+}
+
+// SnapshotWarmUp returns a hook importing the snapshot file at path without replacing existing keys
+func SnapshotWarmUp(path string) WarmUpFunc {
+	return func(ctx context.Context, helper CacheHelper) error {
+		_, err := ImportSnapshotFile(ctx, helper, path, SnapshotImportOption{})
+		return err
+	}
+}
+
+// LoaderWarmUp returns a hook storing the values produced by loader, typically read from the database
+func LoaderWarmUp(loader func(ctx context.Context) (map[string]interface{}, error), expiration time.Duration) WarmUpFunc {
+	return func(ctx context.Context, helper CacheHelper) error {
+		values, err := loader(ctx)
+		if err != nil {
+			return err
+		}
+		for key, value := range values {
+			if err := helper.Set(ctx, key, value, expiration); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
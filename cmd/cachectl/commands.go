@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go-core/cache"
+)
+
+type keySize struct {
+	key     string
+	keyType string
+	size    int64
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("cachectl "+name, flag.ExitOnError)
+}
+
+func runScan(ctx context.Context, helper cache.CacheHelper, args []string) error {
+	fs := newFlagSet("scan")
+	pattern := fs.String("pattern", "*", "key pattern")
+	count := fs.Int64("count", 1000, "SCAN count hint")
+	_ = fs.Parse(args)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tTYPE\tTTL")
+	err := helper.ScanKeys(ctx, *pattern, *count, func(key string) error {
+		keyType, err := helper.GetType(ctx, key)
+		if err != nil {
+			return err
+		}
+		ttl, err := helper.TimeExpire(ctx, key)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key, keyType, formatTTL(ttl))
+		return nil
+	})
+	if errFlush := w.Flush(); err == nil {
+		err = errFlush
+	}
+	return err
+}
+
+func runBigKeys(ctx context.Context, helper cache.CacheHelper, args []string) error {
+	fs := newFlagSet("bigkeys")
+	pattern := fs.String("pattern", "*", "key pattern")
+	count := fs.Int64("count", 1000, "SCAN count hint")
+	top := fs.Int("top", 20, "number of keys to report")
+	minBytes := fs.Int64("min-bytes", 0, "ignore keys smaller than this")
+	_ = fs.Parse(args)
+
+	var sizes []keySize
+	err := helper.ScanKeys(ctx, *pattern, *count, func(key string) error {
+		keyType, err := helper.GetType(ctx, key)
+		if err != nil {
+			return err
+		}
+		size, err := keyBytes(ctx, helper, key, keyType)
+		if err != nil {
+			return err
+		}
+		if size >= *minBytes {
+			sizes = append(sizes, keySize{key: key, keyType: keyType, size: size})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(sizes, func(i, j int) bool {
+		return sizes[i].size > sizes[j].size
+	})
+	if len(sizes) > *top {
+		sizes = sizes[:*top]
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tTYPE\tBYTES")
+	for _, item := range sizes {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", item.key, item.keyType, item.size)
+	}
+	return w.Flush()
+}
+
+// keyBytes returns the string length for strings and the serialized length
+// reported by DEBUG OBJECT for the other types
+func keyBytes(ctx context.Context, helper cache.CacheHelper, key, keyType string) (int64, error) {
+	if keyType == "string" {
+		return helper.GetStrLenght(ctx, key)
+	}
+	debug, err := helper.DebugObjectByKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	for _, field := range strings.Fields(debug) {
+		if value := strings.TrimPrefix(field, "serializedlength:"); value != field {
+			return strconv.ParseInt(value, 10, 64)
+		}
+	}
+	return 0, fmt.Errorf("no serializedlength in debug object of %s", key)
+}
+
+func runNoTTL(ctx context.Context, helper cache.CacheHelper, args []string) error {
+	fs := newFlagSet("nottl")
+	pattern := fs.String("pattern", "*", "key pattern")
+	count := fs.Int64("count", 1000, "SCAN count hint")
+	_ = fs.Parse(args)
+
+	var total int
+	err := helper.ScanKeys(ctx, *pattern, *count, func(key string) error {
+		ttl, err := helper.TimeExpire(ctx, key)
+		if err != nil {
+			return err
+		}
+		// -1 means the key exists without expiration
+		if ttl == -time.Second {
+			total++
+			fmt.Println(key)
+		}
+		return nil
+	})
+	fmt.Fprintf(os.Stderr, "%d keys without TTL\n", total)
+	return err
+}
+
+func runDump(ctx context.Context, helper cache.CacheHelper, args []string) error {
+	fs := newFlagSet("dump")
+	pattern := fs.String("pattern", "*", "key pattern")
+	count := fs.Int64("count", 1000, "SCAN count hint")
+	out := fs.String("out", "", "output file, default stdout")
+	_ = fs.Parse(args)
+
+	var (
+		result cache.SnapshotResult
+		err    error
+	)
+	if *out != "" {
+		result, err = cache.ExportSnapshotFile(ctx, helper, *pattern, *count, *out)
+	} else {
+		result, err = cache.ExportSnapshot(ctx, helper, *pattern, *count, os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d keys dumped%s\n", result.Keys, formatSkipped(result.Skipped))
+	return nil
+}
+
+func runRestore(ctx context.Context, helper cache.CacheHelper, args []string) error {
+	fs := newFlagSet("restore")
+	in := fs.String("in", "", "input file, default stdin")
+	replace := fs.Bool("replace", false, "replace existing keys")
+	dryRun := fs.Bool("dry-run", false, "only print the keys that would be restored")
+	_ = fs.Parse(args)
+
+	var (
+		opt    = cache.SnapshotImportOption{Replace: *replace, DryRun: *dryRun, Output: os.Stdout}
+		result cache.SnapshotResult
+		err    error
+	)
+	if *in != "" {
+		result, err = cache.ImportSnapshotFile(ctx, helper, *in, opt)
+	} else {
+		result, err = cache.ImportSnapshot(ctx, helper, os.Stdin, opt)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d keys restored%s (dry-run: %t)\n", result.Keys, formatSkipped(result.Skipped), *dryRun)
+	return nil
+}
+
+func runDelete(ctx context.Context, helper cache.CacheHelper, args []string) error {
+	fs := newFlagSet("delete")
+	pattern := fs.String("pattern", "", "key pattern, required")
+	count := fs.Int64("count", 1000, "SCAN count hint")
+	dryRun := fs.Bool("dry-run", false, "only print the keys that would be deleted")
+	_ = fs.Parse(args)
+	if *pattern == "" {
+		return errors.New("-pattern is required")
+	}
+
+	keys, err := collectKeys(ctx, helper, *pattern, *count)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		fmt.Println(key)
+	}
+	if !*dryRun {
+		for start := 0; start < len(keys); start += int(*count) {
+			end := start + int(*count)
+			if end > len(keys) {
+				end = len(keys)
+			}
+			if err := helper.DelMulti(ctx, keys[start:end]...); err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d keys deleted (dry-run: %t)\n", len(keys), *dryRun)
+	return nil
+}
+
+func runExpire(ctx context.Context, helper cache.CacheHelper, args []string) error {
+	fs := newFlagSet("expire")
+	pattern := fs.String("pattern", "", "key pattern, required")
+	count := fs.Int64("count", 1000, "SCAN count hint")
+	ttl := fs.Duration("ttl", 0, "new TTL, required")
+	onlyNoTTL := fs.Bool("only-no-ttl", false, "only update keys without expiration")
+	dryRun := fs.Bool("dry-run", false, "only print the keys that would be updated")
+	_ = fs.Parse(args)
+	if *pattern == "" || *ttl <= 0 {
+		return errors.New("-pattern and a positive -ttl are required")
+	}
+
+	keys, err := collectKeys(ctx, helper, *pattern, *count)
+	if err != nil {
+		return err
+	}
+	var total int
+	for _, key := range keys {
+		if *onlyNoTTL {
+			current, err := helper.TimeExpire(ctx, key)
+			if err != nil {
+				return err
+			}
+			if current != -time.Second {
+				continue
+			}
+		}
+		total++
+		fmt.Println(key)
+		if *dryRun {
+			continue
+		}
+		if err := helper.Expire(ctx, key, *ttl); err != nil {
+			return fmt.Errorf("expire %s: %w", key, err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d keys updated (dry-run: %t)\n", total, *dryRun)
+	return nil
+}
+
+func runGet(ctx context.Context, helper cache.CacheHelper, args []string) error {
+	fs := newFlagSet("get")
+	key := fs.String("key", "", "key to decode, required")
+	_ = fs.Parse(args)
+	if *key == "" {
+		return errors.New("-key is required")
+	}
+
+	keyType, err := helper.GetType(ctx, *key)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	switch keyType {
+	case "none":
+		return fmt.Errorf("key %s does not exist", *key)
+	case "string":
+		if err := helper.Get(ctx, *key, &value); err != nil {
+			return err
+		}
+	case "hash":
+		fields, err := helper.HGetAll(ctx, *key, nil)
+		if err != nil {
+			return err
+		}
+		decoded := make(map[string]interface{}, len(fields))
+		for field, raw := range fields {
+			var fieldValue interface{}
+			if json.Unmarshal([]byte(raw), &fieldValue) != nil {
+				fieldValue = raw
+			}
+			decoded[field] = fieldValue
+		}
+		value = decoded
+	default:
+		return fmt.Errorf("decoding %s values is not supported", keyType)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// collectKeys scans all keys first so that mutations do not disturb the SCAN cursor
+func collectKeys(ctx context.Context, helper cache.CacheHelper, pattern string, count int64) ([]string, error) {
+	var keys []string
+	err := helper.ScanKeys(ctx, pattern, count, func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	return keys, err
+}
+
+// formatSkipped reports the keys expired while dumping or already existing while restoring
+func formatSkipped(skipped int) string {
+	if skipped == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", %d skipped", skipped)
+}
+
+func formatTTL(ttl time.Duration) string {
+	switch {
+	case ttl == -time.Second:
+		return "none"
+	case ttl < 0:
+		return "missing"
+	default:
+		return ttl.String()
+	}
+}
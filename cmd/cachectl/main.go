@@ -0,0 +1,90 @@
+// Command cachectl inspects and maintains keys of a redis standalone or cluster deployment
+// through cache.CacheHelper.
+//
+// Usage:
+//
+//	cachectl -addrs 127.0.0.1:6379 [-db 0] <command> [flags]
+//
+// Commands:
+//
+//	scan     list keys matching -pattern with their type and TTL
+//	bigkeys  report the -top largest keys matching -pattern
+//	nottl    list keys matching -pattern without expiration
+//	dump     write keys matching -pattern to a snapshot file (cache.ExportSnapshot)
+//	restore  load keys from a snapshot file (cache.ImportSnapshot)
+//	delete   delete keys matching -pattern
+//	expire   set the TTL of keys matching -pattern
+//	get      decode the value of -key with the helper JSON codec
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"go-core/cache"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, helper cache.CacheHelper, args []string) error
+}
+
+var commands = []command{
+	{name: "scan", usage: "list keys with type and TTL", run: runScan},
+	{name: "bigkeys", usage: "report the largest keys", run: runBigKeys},
+	{name: "nottl", usage: "list keys without expiration", run: runNoTTL},
+	{name: "dump", usage: "dump keys to a JSON-lines file", run: runDump},
+	{name: "restore", usage: "restore keys from a JSON-lines file", run: runRestore},
+	{name: "delete", usage: "delete keys by pattern", run: runDelete},
+	{name: "expire", usage: "set TTL of keys by pattern", run: runExpire},
+	{name: "get", usage: "decode the value of a key", run: runGet},
+}
+
+func main() {
+	addrs := flag.String("addrs", "127.0.0.1:6379", "comma separated redis addresses, more than one address means cluster mode")
+	db := flag.Int("db", 0, "redis database, standalone mode only")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	name := flag.Arg(0)
+	var selected *command
+	for i := range commands {
+		if commands[i].name == name {
+			selected = &commands[i]
+			break
+		}
+	}
+	if selected == nil {
+		fmt.Fprintf(os.Stderr, "cachectl: unknown command %q\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	helper := cache.NewCacheHelper(strings.Split(*addrs, ","), cache.CacheOption{Key: "db", Value: *db})
+	if err := selected.run(ctx, helper, flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "cachectl %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: cachectl [-addrs host:port,...] [-db n] <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'cachectl <command> -h' for command flags.\n\nGlobal flags:\n")
+	flag.PrintDefaults()
+}
@@ -0,0 +1,136 @@
+// Command migrate applies versioned SQL migrations with db/migrate.
+//
+// Usage:
+//
+//	migrate -engine postgres -host localhost -port 5432 -user app -database app -dir migrations <command>
+//
+// Commands:
+//
+//	up         apply every pending migration
+//	down [n]   roll back the last n migrations, default 1
+//	goto <v>   migrate up or down to version v
+//	status     list migrations and whether they are applied
+//
+// The password is read from -password or the MIGRATE_PASSWORD environment variable. On sqlite -database is
+// the file path. The oracle and mysql drivers are not linked in, use migrate.New from a service registering them.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+
+	"go-core/db"
+	"go-core/db/migrate"
+
+	_ "github.com/denisenkom/go-mssqldb"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	var (
+		engine   = flag.String("engine", "postgres", "database engine: postgres, sqlserver or sqlite")
+		host     = flag.String("host", "localhost", "database host")
+		port     = flag.Int("port", 5432, "database port")
+		user     = flag.String("user", "", "database user")
+		password = flag.String("password", os.Getenv("MIGRATE_PASSWORD"), "database password, default $MIGRATE_PASSWORD")
+		database = flag.String("database", "", "database name, file path on sqlite")
+		sslMode  = flag.String("sslmode", "", "disable, require, verify-ca or verify-full")
+		dir      = flag.String("dir", "migrations", "directory of <version>_<name>.(up|down).sql files")
+		table    = flag.String("table", "schema_migrations", "table recording applied versions")
+		dryRun   = flag.Bool("dry-run", false, "print the plan without executing it")
+	)
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, *engine, db.DBConfig{
+		Host:     *host,
+		Port:     *port,
+		Username: *user,
+		Password: *password,
+		Database: *database,
+		SSLMode:  *sslMode,
+	}, *dir, migrate.Option{Table: *table, DryRun: *dryRun, Output: os.Stdout}, flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, engine string, cfg db.DBConfig, dir string, option migrate.Option, args []string) error {
+	migrations, err := migrate.LoadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var helper db.DBHelper
+	switch engine {
+	case "postgres":
+		helper, err = db.OpenPostgresDBHelper(ctx, cfg)
+	case "sqlserver":
+		helper, err = db.OpenSQLServerDBHelper(ctx, cfg)
+	case "sqlite":
+		helper, err = db.OpenSQLiteDBHelper(ctx, db.DBConfig{Database: cfg.Database})
+	default:
+		return fmt.Errorf("unknown engine %q", engine)
+	}
+	if err != nil {
+		return err
+	}
+	defer helper.Close()
+
+	migrator, err := migrate.New(helper, migrations, option)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+		}
+		return migrator.Down(ctx, steps)
+	case "goto":
+		if len(args) < 2 {
+			return fmt.Errorf("goto requires a version")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		return migrator.To(ctx, version)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
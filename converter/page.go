@@ -0,0 +1,71 @@
+package helper
+
+import (
+	"math"
+	"reflect"
+
+	"go-core/db"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/jinzhu/copier"
+)
+
+// PageRequestFromPb reads the PageSize and PageToken fields of a list request, count fills Page.TotalSize
+func PageRequestFromPb(from proto.Message, count bool) db.PageRequest {
+	request := db.PageRequest{Count: count}
+	value := reflect.Indirect(reflect.ValueOf(from))
+	if value.Kind() != reflect.Struct {
+		return request
+	}
+	if field := value.FieldByName("PageSize"); field.IsValid() {
+		// values above MaxUint32 are saturated rather than truncated, SelectPage caps them to db.MaxPageSize
+		switch field.Kind() {
+		case reflect.Int32, reflect.Int64:
+			if size := field.Int(); size > math.MaxUint32 {
+				request.PageSize = math.MaxUint32
+			} else if size > 0 {
+				request.PageSize = uint32(size)
+			}
+		case reflect.Uint32, reflect.Uint64:
+			if size := field.Uint(); size > math.MaxUint32 {
+				request.PageSize = math.MaxUint32
+			} else {
+				request.PageSize = uint32(size)
+			}
+		}
+	}
+	if field := value.FieldByName("PageToken"); field.IsValid() && field.Kind() == reflect.String {
+		request.PageToken = field.String()
+	}
+	return request
+}
+
+// PageToPb fills a list response: the items are copied into its first repeated message field, NextPageToken
+// and TotalSize are set when the response declares them (TotalSize only when the page was counted)
+func PageToPb[T any](to proto.Message, page db.Page[T]) {
+	value := reflect.Indirect(reflect.ValueOf(to))
+	if value.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 && field.CanSet() &&
+			value.Type().Field(i).Tag.Get("protobuf") != "" {
+			items := reflect.New(field.Type())
+			_ = copier.Copy(items.Interface(), page.Items)
+			field.Set(items.Elem())
+			break
+		}
+	}
+	if field := value.FieldByName("NextPageToken"); field.IsValid() && field.Kind() == reflect.String {
+		field.SetString(page.NextPageToken)
+	}
+	if field := value.FieldByName("TotalSize"); field.IsValid() && page.TotalSize >= 0 {
+		switch field.Kind() {
+		case reflect.Int32, reflect.Int64:
+			field.SetInt(page.TotalSize)
+		case reflect.Uint32, reflect.Uint64:
+			field.SetUint(uint64(page.TotalSize))
+		}
+	}
+}
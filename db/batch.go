@@ -0,0 +1,291 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const defaultBatchSize = 500
+
+var (
+	// ErrBatchInvalidRow is returned when a row does not have one value per column
+	ErrBatchInvalidRow = errors.New("db: row values do not match the columns")
+	// ErrArrayBindingUnsupported is returned for BatchOption.ArrayBinding when the driver cannot bind slices, e.g. oci8
+	ErrArrayBindingUnsupported = errors.New("db: the driver does not support array binding")
+)
+
+// arrayBindingDrivers are the package paths of the Oracle drivers binding a slice per column
+var arrayBindingDrivers = []string{"github.com/godror/godror"}
+
+type (
+	// BatchOption configures BulkInsert
+	BatchOption struct {
+		// BatchSize is the maximum number of rows per statement, default 500. It is lowered to
+		// respect the bind parameter limits of the engine (e.g. 1000 rows and 2100 parameters on SQL Server).
+		BatchSize int
+		// Transaction runs every batch in a single transaction, the first failure rolls all of them back.
+		// A transaction carried by ctx (see ContextWithTx) is always used.
+		Transaction bool
+		// ContinueOnError executes the remaining batches after a failure, ignored inside a transaction
+		ContinueOnError bool
+		// Copy streams the rows with COPY FROM STDIN on Postgres, it needs the lib/pq driver
+		Copy bool
+		// ArrayBinding executes a single row INSERT per batch with one slice argument per column on Oracle,
+		// it needs the godror driver, ErrArrayBindingUnsupported is returned with others such as oci8
+		ArrayBinding bool
+	}
+
+	// BatchError is the failure of a single batch
+	BatchError struct {
+		// Batch is the 0-based batch index, Offset the index of its first row and Rows its size
+		Batch  int
+		Offset int
+		Rows   int
+		Err    error
+	}
+
+	// BatchResult summarizes a BulkInsert
+	BatchResult struct {
+		// Rows is the number of inserted rows, Batches the number of executed batches
+		Rows    int64
+		Batches int
+		Errors  []*BatchError
+	}
+
+	// execer is implemented by *sql.DB and *sql.Tx
+	execer interface {
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+		PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	}
+)
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch %d (rows %d-%d): %v", e.Batch, e.Offset, e.Offset+e.Rows-1, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// BulkInsert inserts rows into table in batches of multi row INSERTs rendered for the helper dialect
+// (INSERT ALL on Oracle). Table and column names are written as given. The first *BatchError is returned,
+// with ContinueOnError every failure is also listed in the result.
+func BulkInsert(ctx context.Context, helper DBHelper, table string, columns []string, rows [][]interface{},
+	opt BatchOption) (BatchResult, error) {
+	for _, row := range rows {
+		if len(row) != len(columns) {
+			return BatchResult{}, ErrBatchInvalidRow
+		}
+	}
+	if opt.ArrayBinding && helper.Dialect().Name() == "oracle" && !bindsArrays(helper.Open().Driver()) {
+		return BatchResult{}, ErrArrayBindingUnsupported
+	}
+	size := batchSize(helper.Dialect(), len(columns), opt)
+	copyIn := opt.Copy && helper.Dialect().Name() == "postgres"
+
+	if tx, ok := TxFromContext(ctx); ok {
+		return insertBatches(ctx, tx, helper.Dialect(), table, columns, rows, size, copyIn, opt, false)
+	}
+	if opt.Transaction {
+		var result BatchResult
+		err := helper.WithTxContext(ctx, nil, func(ctx context.Context, tx *sql.Tx) (err error) {
+			result, err = insertBatches(ctx, tx, helper.Dialect(), table, columns, rows, size, copyIn, opt, false)
+			return err
+		})
+		if err != nil {
+			result.Rows = 0
+		}
+		return result, err
+	}
+	// without a transaction COPY batches commit one by one
+	return insertBatches(ctx, helper.Open(), helper.Dialect(), table, columns, rows, size, copyIn, opt, opt.ContinueOnError)
+}
+
+func insertBatches(ctx context.Context, exec execer, dialect Dialect, table string, columns []string, rows [][]interface{},
+	size int, copyIn bool, opt BatchOption, continueOnError bool) (BatchResult, error) {
+	result := BatchResult{}
+	var first error
+	for offset := 0; offset < len(rows); offset += size {
+		end := offset + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+		batch := rows[offset:end]
+
+		var affected int64
+		var err error
+		switch {
+		case copyIn:
+			affected, err = copyBatch(ctx, exec, table, columns, batch)
+		case opt.ArrayBinding && dialect.Name() == "oracle":
+			affected, err = arrayBatch(ctx, exec, dialect, table, columns, batch)
+		default:
+			affected, err = insertBatch(ctx, exec, dialect, table, columns, batch)
+		}
+		result.Batches++
+		if err != nil {
+			batchErr := &BatchError{Batch: result.Batches - 1, Offset: offset, Rows: len(batch), Err: err}
+			result.Errors = append(result.Errors, batchErr)
+			if first == nil {
+				first = batchErr
+			}
+			if !continueOnError || ctx.Err() != nil {
+				return result, first
+			}
+			continue
+		}
+		result.Rows += affected
+	}
+	return result, first
+}
+
+// bindsArrays reports whether d accepts a slice argument per column
+func bindsArrays(d driver.Driver) bool {
+	t := reflect.TypeOf(d)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for _, path := range arrayBindingDrivers {
+		if t.PkgPath() == path {
+			return true
+		}
+	}
+	return false
+}
+
+// batchSize caps the rows per statement with the parameter limits of the engine
+func batchSize(dialect Dialect, columns int, opt BatchOption) int {
+	size := opt.BatchSize
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+	if opt.Copy && dialect.Name() == "postgres" {
+		return size
+	}
+	if opt.ArrayBinding && dialect.Name() == "oracle" {
+		return size
+	}
+	maxRows, maxParams := 0, 65535
+	switch dialect.Name() {
+	case "sqlserver":
+		maxRows, maxParams = 1000, 2100-1
+	case "sqlite":
+		maxParams = 32766
+	}
+	if maxRows > 0 && size > maxRows {
+		size = maxRows
+	}
+	if columns > 0 && size*columns > maxParams {
+		size = maxParams / columns
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// insertStatement renders a multi row INSERT of rows rows
+func insertStatement(dialect Dialect, table string, columns []string, rows int) string {
+	builder := strings.Builder{}
+	list := strings.Join(columns, ", ")
+	index := 0
+	values := func() {
+		builder.WriteString("(")
+		for i := range columns {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			index++
+			builder.WriteString(dialect.Placeholder(index))
+		}
+		builder.WriteString(")")
+	}
+	if dialect.Name() == "oracle" {
+		builder.WriteString("INSERT ALL")
+		for row := 0; row < rows; row++ {
+			builder.WriteString(" INTO " + table + " (" + list + ") VALUES ")
+			values()
+		}
+		builder.WriteString(" SELECT 1 FROM DUAL")
+		return builder.String()
+	}
+	builder.WriteString("INSERT INTO " + table + " (" + list + ") VALUES ")
+	for row := 0; row < rows; row++ {
+		if row > 0 {
+			builder.WriteString(", ")
+		}
+		values()
+	}
+	return builder.String()
+}
+
+func insertBatch(ctx context.Context, exec execer, dialect Dialect, table string, columns []string,
+	rows [][]interface{}) (int64, error) {
+	agruments := make([]interface{}, 0, len(rows)*len(columns))
+	for _, row := range rows {
+		agruments = append(agruments, row...)
+	}
+	result, err := exec.ExecContext(ctx, insertStatement(dialect, table, columns, len(rows)), agruments...)
+	if err != nil {
+		return 0, err
+	}
+	// INSERT ALL reports the rows of every INTO clause, which is the row count as well
+	return result.RowsAffected()
+}
+
+// arrayBatch binds one slice per column to a single row INSERT
+func arrayBatch(ctx context.Context, exec execer, dialect Dialect, table string, columns []string,
+	rows [][]interface{}) (int64, error) {
+	agruments := make([]interface{}, len(columns))
+	for i := range columns {
+		values := make([]interface{}, len(rows))
+		for j, row := range rows {
+			values[j] = row[i]
+		}
+		agruments[i] = values
+	}
+	result, err := exec.ExecContext(ctx, insertStatement(dialect, table, columns, 1), agruments...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// copyBatch streams rows with COPY FROM STDIN, a *sql.DB runs it in a transaction of its own
+func copyBatch(ctx context.Context, exec execer, table string, columns []string, rows [][]interface{}) (affected int64, err error) {
+	if db, ok := exec.(*sql.DB); ok {
+		tx, errBegin := db.BeginTx(ctx, nil)
+		if errBegin != nil {
+			return 0, errBegin
+		}
+		defer func() {
+			if err != nil {
+				_ = tx.Rollback()
+				return
+			}
+			err = tx.Commit()
+		}()
+		exec = tx
+	}
+
+	stmt, err := exec.PrepareContext(ctx, "COPY "+table+" ("+strings.Join(columns, ", ")+") FROM STDIN")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return 0, err
+		}
+	}
+	// an empty Exec flushes the buffered rows
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, err
+	}
+	return int64(len(rows)), nil
+}
@@ -0,0 +1,121 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go-core/db"
+	"go-core/db/dbtest"
+)
+
+func newItems(t *testing.T) (db.DBHelper, *dbtest.Recorder) {
+	t.Helper()
+	helper, recorder := dbtest.NewSQLite(t)
+	if _, err := helper.ExecContext(context.Background(), "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	recorder.Reset()
+	return helper, recorder
+}
+
+func itemRows(ids ...int) [][]interface{} {
+	rows := make([][]interface{}, len(ids))
+	for i, id := range ids {
+		rows[i] = []interface{}{id, "item"}
+	}
+	return rows
+}
+
+func countItems(t *testing.T, helper db.DBHelper) int64 {
+	t.Helper()
+	count, err := db.Get[int64](context.Background(), helper, "SELECT COUNT(*) FROM items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestBulkInsert(t *testing.T) {
+	helper, recorder := newItems(t)
+	result, err := db.BulkInsert(context.Background(), helper, "items", []string{"id", "name"}, itemRows(1, 2, 3, 4, 5),
+		db.BatchOption{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("BulkInsert() error = %v", err)
+	}
+	if result.Rows != 5 || result.Batches != 3 {
+		t.Errorf("BulkInsert() = %+v, want 5 rows in 3 batches", result)
+	}
+	recorder.AssertExecuted(t, "INSERT INTO items (id, name) VALUES (?, ?), (?, ?)", 1, "item", 2, "item")
+	recorder.AssertExecuted(t, "INSERT INTO items (id, name) VALUES (?, ?)", 5, "item")
+	if count := countItems(t, helper); count != 5 {
+		t.Errorf("items = %d, want 5", count)
+	}
+}
+
+func TestBulkInsertFailures(t *testing.T) {
+	// id 3 already exists, so the second batch fails
+	rows := itemRows(1, 2, 3, 4, 5)
+	tests := []struct {
+		name      string
+		opt       db.BatchOption
+		wantRows  int64
+		wantCount int64
+		wantErrs  int
+	}{
+		{name: "stop", opt: db.BatchOption{BatchSize: 2}, wantRows: 2, wantCount: 3, wantErrs: 1},
+		{name: "continue", opt: db.BatchOption{BatchSize: 2, ContinueOnError: true}, wantRows: 3, wantCount: 4, wantErrs: 1},
+		{name: "transaction", opt: db.BatchOption{BatchSize: 2, Transaction: true}, wantRows: 0, wantCount: 1, wantErrs: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helper, _ := newItems(t)
+			if _, err := helper.ExecContext(context.Background(), "INSERT INTO items (id, name) VALUES (3, 'existing')"); err != nil {
+				t.Fatal(err)
+			}
+			result, err := db.BulkInsert(context.Background(), helper, "items", []string{"id", "name"}, rows, tt.opt)
+			var batchErr *db.BatchError
+			if !errors.As(err, &batchErr) || batchErr.Batch != 1 || batchErr.Offset != 2 || batchErr.Rows != 2 {
+				t.Fatalf("BulkInsert() error = %v, want the failure of batch 1", err)
+			}
+			if result.Rows != tt.wantRows || len(result.Errors) != tt.wantErrs {
+				t.Errorf("BulkInsert() = %+v, want %d rows and %d errors", result, tt.wantRows, tt.wantErrs)
+			}
+			if count := countItems(t, helper); count != tt.wantCount {
+				t.Errorf("items = %d, want %d", count, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestBulkInsertInvalidRow(t *testing.T) {
+	helper, recorder := newItems(t)
+	_, err := db.BulkInsert(context.Background(), helper, "items", []string{"id", "name"}, [][]interface{}{{1}}, db.BatchOption{})
+	if err != db.ErrBatchInvalidRow {
+		t.Errorf("BulkInsert() error = %v, want %v", err, db.ErrBatchInvalidRow)
+	}
+	if statements := recorder.Statements(); len(statements) != 0 {
+		t.Errorf("executed %v", statements)
+	}
+}
+
+func TestBulkInsertOracle(t *testing.T) {
+	helper, fake := dbtest.NewFake(t, db.OracleDialect)
+	fake.StubExec("INSERT ALL", 2, 0)
+	result, err := db.BulkInsert(context.Background(), helper, "items", []string{"id", "name"}, itemRows(1, 2), db.BatchOption{})
+	if err != nil || result.Rows != 2 {
+		t.Fatalf("BulkInsert() = %+v, %v", result, err)
+	}
+	statements := fake.Statements()
+	want := "INSERT ALL INTO items (id, name) VALUES (:1, :2) INTO items (id, name) VALUES (:3, :4) SELECT 1 FROM DUAL"
+	if len(statements) != 1 || !strings.Contains(statements[0].Query, want) {
+		t.Errorf("statements = %v, want %s", statements, want)
+	}
+
+	// the fake driver, like oci8, cannot bind a slice per column
+	if _, err := db.BulkInsert(context.Background(), helper, "items", []string{"id", "name"}, itemRows(1, 2),
+		db.BatchOption{ArrayBinding: true}); err != db.ErrArrayBindingUnsupported {
+		t.Errorf("BulkInsert() error = %v, want %v", err, db.ErrArrayBindingUnsupported)
+	}
+}
@@ -0,0 +1,219 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+)
+
+// ErrMySQLUsername is returned for a MySQL user name containing a colon, the driver would split it as the password
+var ErrMySQLUsername = errors.New("db: mysql user name must not contain a colon")
+
+// DBConfig represents the connection and pool configuration shared by every engine
+type DBConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Database string
+	// Credentials supplies the username and password of every new connection instead of Username and Password,
+	// pooled connections opened with previous credentials are recycled after a rotation
+	Credentials CredentialsProvider
+
+	// SSLMode follows the postgres sslmode values: disable, require, verify-ca, verify-full.
+	// Empty keeps the driver default.
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	// MaxOpenConns, MaxIdleConns, ConnMaxLifetime and ConnMaxIdleTime configure the sql.DB pool,
+	// zero keeps the database/sql default
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	ApplicationName string
+	// Params are extra driver parameters appended to the DSN
+	Params map[string]string
+
+	// QueryTimeout is the default timeout of queries whose context has no deadline
+	QueryTimeout time.Duration
+
+	// SlowQueryThreshold logs the statements running longer, zero disables the log
+	SlowQueryThreshold time.Duration
+
+	// StatsInterval exports sql.DBStats as metrics and log lines periodically, zero disables it
+	StatsInterval time.Duration
+	// MetricsFactory receives the pool metrics, default metrics.NullFactory
+	MetricsFactory metrics.Factory
+}
+
+// Address returns host:port
+func (cfg DBConfig) Address() string {
+	return fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+}
+
+// applyPool configures the connection pool of db
+func (cfg DBConfig) applyPool(db *sql.DB) {
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+}
+
+// options converts the config to helper options, explicit options take precedence
+func (cfg DBConfig) options(opts []DBOption) []DBOption {
+	var result []DBOption
+	if cfg.QueryTimeout > 0 {
+		result = append(result, DBOption{Key: "query_timeout", Value: cfg.QueryTimeout})
+	}
+	if cfg.SlowQueryThreshold > 0 {
+		result = append(result, DBOption{Key: "slow_query_threshold", Value: cfg.SlowQueryThreshold})
+	}
+	return append(result, opts...)
+}
+
+// postgresDSN builds a key/value connection string, values are quoted and escaped
+func postgresDSN(cfg DBConfig) (string, error) {
+	params := map[string]string{
+		"host":     cfg.Host,
+		"port":     fmt.Sprint(cfg.Port),
+		"user":     cfg.Username,
+		"password": cfg.Password,
+		"dbname":   cfg.Database,
+	}
+	setNotEmpty(params, "sslmode", cfg.SSLMode)
+	setNotEmpty(params, "sslrootcert", cfg.SSLRootCert)
+	setNotEmpty(params, "sslcert", cfg.SSLCert)
+	setNotEmpty(params, "sslkey", cfg.SSLKey)
+	setNotEmpty(params, "application_name", cfg.ApplicationName)
+	for key, value := range cfg.Params {
+		params[key] = value
+	}
+
+	pairs := make([]string, 0, len(params))
+	for _, key := range sortedKeys(params) {
+		value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(params[key])
+		pairs = append(pairs, fmt.Sprintf("%s='%s'", key, value))
+	}
+	return strings.Join(pairs, " "), nil
+}
+
+// oracleDSN builds a user/password@host:port/service connection string, credentials are query escaped
+func oracleDSN(cfg DBConfig) (string, error) {
+	dsn := fmt.Sprintf("%s/%s@%s:%d/%s", url.QueryEscape(cfg.Username), url.QueryEscape(cfg.Password),
+		cfg.Host, cfg.Port, cfg.Database)
+	values := url.Values{}
+	for key, value := range cfg.Params {
+		values.Set(key, value)
+	}
+	if len(values) > 0 {
+		dsn += "?" + values.Encode()
+	}
+	return dsn, nil
+}
+
+// sqlServerDSN builds a sqlserver:// URL, TLS follows SSLMode: disable turns encryption off,
+// require encrypts without verifying the certificate and verify-* verifies it against SSLRootCert
+func sqlServerDSN(cfg DBConfig) (string, error) {
+	values := url.Values{}
+	values.Set("database", cfg.Database)
+	setNotEmptyValue(values, "app name", cfg.ApplicationName)
+	switch cfg.SSLMode {
+	case "disable":
+		values.Set("encrypt", "disable")
+	case "require":
+		values.Set("encrypt", "true")
+		values.Set("TrustServerCertificate", "true")
+	case "verify-ca", "verify-full":
+		values.Set("encrypt", "true")
+		values.Set("TrustServerCertificate", "false")
+		setNotEmptyValue(values, "certificate", cfg.SSLRootCert)
+	}
+	for key, value := range cfg.Params {
+		values.Set(key, value)
+	}
+	dsn := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(cfg.Username, cfg.Password),
+		Host:     cfg.Address(),
+		RawQuery: values.Encode(),
+	}
+	return dsn.String(), nil
+}
+
+// mysqlDSN builds a go-sql-driver/mysql user:password@tcp(host:port)/database DSN with parseTime enabled.
+// TLS follows SSLMode: disable turns it off, require skips verification and verify-* verifies against
+// the system roots, a custom CA must be registered with mysql.RegisterTLSConfig and named in Params["tls"].
+func mysqlDSN(cfg DBConfig) (string, error) {
+	// the driver splits the credentials on the first colon and the last @, so only the user name needs care
+	if strings.Contains(cfg.Username, ":") {
+		return "", ErrMySQLUsername
+	}
+	values := url.Values{}
+	values.Set("parseTime", "true")
+	switch cfg.SSLMode {
+	case "disable":
+		values.Set("tls", "false")
+	case "require":
+		values.Set("tls", "skip-verify")
+	case "verify-ca", "verify-full":
+		values.Set("tls", "true")
+	}
+	if cfg.ApplicationName != "" {
+		values.Set("connectionAttributes", "program_name:"+cfg.ApplicationName)
+	}
+	for key, value := range cfg.Params {
+		values.Set(key, value)
+	}
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s?%s", cfg.Username, cfg.Password, cfg.Address(), cfg.Database, values.Encode()), nil
+}
+
+// sqliteDSN builds a file DSN for mattn/go-sqlite3, Params are appended as query parameters
+func sqliteDSN(cfg DBConfig) (string, error) {
+	if len(cfg.Params) == 0 {
+		return cfg.Database, nil
+	}
+	values := url.Values{}
+	for key, value := range cfg.Params {
+		values.Set(key, value)
+	}
+	return "file:" + cfg.Database + "?" + values.Encode(), nil
+}
+
+func setNotEmpty(params map[string]string, key, value string) {
+	if value != "" {
+		params[key] = value
+	}
+}
+
+func setNotEmptyValue(values url.Values, key, value string) {
+	if value != "" {
+		values.Set(key, value)
+	}
+}
+
+func sortedKeys(params map[string]string) []string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
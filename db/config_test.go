@@ -0,0 +1,72 @@
+package db
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDSN(t *testing.T) {
+	tests := []struct {
+		name    string
+		dsn     func(cfg DBConfig) (string, error)
+		cfg     DBConfig
+		want    string
+		wantErr error
+	}{
+		{
+			name: "postgres quotes and escapes values",
+			dsn:  postgresDSN,
+			cfg: DBConfig{Host: "h", Port: 5432, Username: "u", Password: `p'a\ss word`, Database: "d", SSLMode: "require",
+				Params: map[string]string{"connect_timeout": "5"}},
+			want: `connect_timeout='5' dbname='d' host='h' password='p\'a\\ss word' port='5432' sslmode='require' user='u'`,
+		},
+		{
+			name: "oracle escapes credentials",
+			dsn:  oracleDSN,
+			cfg:  DBConfig{Host: "h", Port: 1521, Username: "u@x", Password: "p/a@ss", Database: "svc", Params: map[string]string{"a": "b c"}},
+			want: "u%40x/p%2Fa%40ss@h:1521/svc?a=b+c",
+		},
+		{
+			name: "sqlserver verifies the certificate",
+			dsn:  sqlServerDSN,
+			cfg: DBConfig{Host: "h", Port: 1433, Username: "u", Password: "p@ss:w/rd", Database: "d", SSLMode: "verify-full",
+				SSLRootCert: "/ca.pem", ApplicationName: "app"},
+			want: "sqlserver://u:p%40ss%3Aw%2Frd@h:1433?TrustServerCertificate=false&app+name=app&certificate=%2Fca.pem&database=d&encrypt=true",
+		},
+		{
+			name: "mysql keeps special characters of the password",
+			dsn:  mysqlDSN,
+			cfg:  DBConfig{Host: "h", Port: 3306, Username: "u", Password: "p@ss:w", Database: "d", SSLMode: "require", ApplicationName: "app"},
+			want: "u:p@ss:w@tcp(h:3306)/d?connectionAttributes=program_name%3Aapp&parseTime=true&tls=skip-verify",
+		},
+		{
+			name:    "mysql rejects a colon in the user",
+			dsn:     mysqlDSN,
+			cfg:     DBConfig{Host: "h", Port: 3306, Username: "u:x", Password: "p", Database: "d"},
+			wantErr: ErrMySQLUsername,
+		},
+		{
+			name: "sqlite path",
+			dsn:  sqliteDSN,
+			cfg:  DBConfig{Database: "/tmp/a.db"},
+			want: "/tmp/a.db",
+		},
+		{
+			name: "sqlite params",
+			dsn:  sqliteDSN,
+			cfg:  DBConfig{Database: "/tmp/a.db", Params: map[string]string{"_busy_timeout": "5000", "mode": "ro"}},
+			want: "file:/tmp/a.db?_busy_timeout=5000&mode=ro",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.dsn(tt.cfg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("dsn error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("dsn = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	log "go-core/log"
+)
+
+const (
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff     = 10 * time.Second
+	defaultRetryMultiplier     = 2
+)
+
+// RetryPolicy retries the initial connection with exponential backoff and jitter
+type RetryPolicy struct {
+	// MaxAttempts bounds the attempts, zero means unlimited (bounded by MaxWait and the context)
+	MaxAttempts int
+	// InitialBackoff is the first delay, default 100ms, it grows by Multiplier (default 2) up to MaxBackoff (default 10s)
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// MaxWait bounds the total time spent connecting, zero means no bound
+	MaxWait time.Duration
+}
+
+// DefaultRetryPolicy retries for up to a minute
+var DefaultRetryPolicy = RetryPolicy{MaxWait: time.Minute}
+
+// Retry calls fn until it succeeds, the attempts or MaxWait are exhausted or ctx is done,
+// the last error of fn is returned
+func (p RetryPolicy) Retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	if p.MaxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.MaxWait)
+		defer cancel()
+	}
+	backoff := p.InitialBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryInitialBackoff
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = defaultRetryMultiplier
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || (p.MaxAttempts > 0 && attempt >= p.MaxAttempts) {
+			return err
+		}
+		// equal jitter keeps at least half of the backoff between attempts
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		log.Logger.Warnw("Retrying database connection", "attempt", attempt, "backoff", delay.String(), "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		backoff = time.Duration(float64(backoff) * multiplier)
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// openBaseDBHelper opens the pool and connects according to the connect_retry and lazy_connect options,
+// with DBConfig.Credentials every connection is opened with the credentials current at that time
+func openBaseDBHelper(ctx context.Context, name, driverName string, dsn func(cfg DBConfig) (string, error), dialect Dialect,
+	cfg DBConfig, opts []DBOption) (*baseDBHelper, error) {
+	connector, err := newConnector(driverName, dsn, cfg)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(connector)
+	cfg.applyPool(db)
+	helper := newBaseDBHelper(name, db, dialect, cfg, opts)
+	if connector.provider != nil && helper.credentialsRefresh > 0 {
+		go connector.refresh(helper.credentialsRefresh, helper.stop)
+	}
+
+	if helper.lazyConnect {
+		go helper.connectLazily()
+		return helper, nil
+	}
+	if err := helper.connectRetry.Retry(ctx, db.PingContext); err != nil {
+		_ = helper.Close()
+		return nil, err
+	}
+	atomic.StoreInt32(&helper.ready, 1)
+	return helper, nil
+}
+
+// connectLazily pings in the background until the database is reachable or the helper is closed
+func (h *baseDBHelper) connectLazily() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-h.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	policy := h.connectRetry
+	policy.MaxAttempts, policy.MaxWait = 0, 0
+	if err := policy.Retry(ctx, h.db.PingContext); err != nil {
+		return
+	}
+	atomic.StoreInt32(&h.ready, 1)
+	log.Logger.Infow("Database connection ready", "db.type", h.dialect.Name(), "db.instance", h.config.Database)
+}
+
+// Ready reports whether the first connection succeeded, it is false until a lazily connecting helper reaches the database
+func (h *baseDBHelper) Ready() bool {
+	return atomic.LoadInt32(&h.ready) == 1
+}
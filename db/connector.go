@@ -0,0 +1,413 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "go-core/log"
+)
+
+type (
+	// connector opens the connections of every helper pool. With DBConfig.Credentials each connection is opened
+	// with the current credentials of the provider: the generation changes when the credentials do and
+	// connections of older generations are discarded instead of being reused.
+	connector struct {
+		driver driver.Driver
+		// base opens the connections without credentials provider
+		base     driver.Connector
+		dsn      func(cfg DBConfig) (string, error)
+		cfg      DBConfig
+		provider CredentialsProvider
+
+		mutex      sync.Mutex
+		known      bool
+		current    Credentials
+		generation int64
+	}
+
+	// dsnConnector opens connections of drivers which do not implement driver.DriverContext
+	dsnConnector struct {
+		driver driver.Driver
+		dsn    string
+	}
+
+	// connectorConn forwards the optional driver interfaces of the wrapped connection
+	connectorConn struct {
+		driver.Conn
+		connector  *connector
+		generation int64
+	}
+
+	connectorStmt struct {
+		driver.Stmt
+	}
+
+	// connectorRows calls the rows close hook of the query context once the rows are closed,
+	// with the number of rows read and the first error of the iteration
+	connectorRows struct {
+		driver.Rows
+		closed    func(count int64, err error)
+		closeOnce sync.Once
+		count     int64
+		err       error
+	}
+
+	rowsClosedKey struct{}
+)
+
+// newConnector uses the driver registered as driverName, sql.Open does not connect
+func newConnector(driverName string, dsn func(cfg DBConfig) (string, error), cfg DBConfig) (*connector, error) {
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	c := &connector{driver: db.Driver(), dsn: dsn, cfg: cfg, provider: cfg.Credentials}
+	if c.provider == nil {
+		name, err := dsn(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if c.base, err = openConnector(c.driver, name); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// wrapConnector wraps the connections of base
+func wrapConnector(base driver.Connector) *connector {
+	return &connector{driver: base.Driver(), base: base}
+}
+
+func openConnector(d driver.Driver, dsn string) (driver.Connector, error) {
+	if driverContext, ok := d.(driver.DriverContext); ok {
+		return driverContext.OpenConnector(dsn)
+	}
+	return dsnConnector{driver: d, dsn: dsn}, nil
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if c.provider == nil {
+		conn, err := c.base.Connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &connectorConn{Conn: conn, connector: c}, nil
+	}
+
+	credentials, err := c.provider.Credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	generation := c.observe(credentials)
+	cfg := c.cfg
+	if credentials.Username != "" {
+		cfg.Username = credentials.Username
+	}
+	cfg.Password = credentials.Password
+	name, err := c.dsn(cfg)
+	if err != nil {
+		return nil, err
+	}
+	base, err := openConnector(c.driver, name)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &connectorConn{Conn: conn, connector: c, generation: generation}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// observe records credentials and returns the current generation
+func (c *connector) observe(credentials Credentials) int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.known && credentials != c.current {
+		atomic.AddInt64(&c.generation, 1)
+		log.Logger.Infow("Database credentials rotated, recycling pooled connections",
+			"db.instance", c.cfg.Database, "db.user", credentials.Username)
+	}
+	c.known, c.current = true, credentials
+	return atomic.LoadInt64(&c.generation)
+}
+
+// refresh polls the provider so that idle connections are recycled after a rotation even when no connection is opened
+func (c *connector) refresh(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			credentials, err := c.provider.Credentials(ctx)
+			cancel()
+			if err != nil {
+				log.Logger.Warnw("Failed to refresh database credentials", "db.instance", c.cfg.Database, "error", err)
+				continue
+			}
+			c.observe(credentials)
+		}
+	}
+}
+
+// withRowsClosed returns a context whose query rows call closed once they are closed
+func withRowsClosed(ctx context.Context, closed func(count int64, err error)) context.Context {
+	return context.WithValue(ctx, rowsClosedKey{}, closed)
+}
+
+// wrapRows returns a function attaching the close hook of ctx to the rows of a query
+func wrapRows(ctx context.Context) func(rows driver.Rows, err error) (driver.Rows, error) {
+	return func(rows driver.Rows, err error) (driver.Rows, error) {
+		closed, ok := ctx.Value(rowsClosedKey{}).(func(count int64, err error))
+		if err != nil || !ok {
+			return rows, err
+		}
+		return &connectorRows{Rows: rows, closed: closed}, nil
+	}
+}
+
+func (c *connectorConn) stale() bool {
+	return atomic.LoadInt64(&c.connector.generation) != c.generation
+}
+
+// IsValid keeps the connection out of the pool once the credentials rotated
+func (c *connectorConn) IsValid() bool {
+	if c.stale() {
+		return false
+	}
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// ResetSession discards an idle connection opened with rotated credentials before it is reused
+func (c *connectorConn) ResetSession(ctx context.Context) error {
+	if c.stale() {
+		return driver.ErrBadConn
+	}
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *connectorConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *connectorConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+func (c *connectorConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &connectorStmt{Stmt: stmt}, nil
+}
+
+func (c *connectorConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	preparer, ok := c.Conn.(driver.ConnPrepareContext)
+	if !ok {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return c.Prepare(query)
+	}
+	stmt, err := preparer.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &connectorStmt{Stmt: stmt}, nil
+}
+
+func (c *connectorConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) || opts.ReadOnly {
+		return nil, errors.New("db: driver does not support isolation levels or read only transactions")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Conn.Begin()
+}
+
+func (c *connectorConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, query, args)
+	}
+	if execer, ok := c.Conn.(driver.Execer); ok {
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return execer.Exec(query, values)
+	}
+	// database/sql prepares the statement instead
+	return nil, driver.ErrSkip
+}
+
+func (c *connectorConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return wrapRows(ctx)(queryer.QueryContext(ctx, query, args))
+	}
+	if queryer, ok := c.Conn.(driver.Queryer); ok {
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return wrapRows(ctx)(queryer.Query(query, values))
+	}
+	return nil, driver.ErrSkip
+}
+
+func (s *connectorStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return execer.ExecContext(ctx, args)
+	}
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(values)
+}
+
+func (s *connectorStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return wrapRows(ctx)(queryer.QueryContext(ctx, args))
+	}
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return wrapRows(ctx)(s.Stmt.Query(values))
+}
+
+func (s *connectorStmt) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+func (s *connectorStmt) ColumnConverter(index int) driver.ValueConverter {
+	if converter, ok := s.Stmt.(driver.ColumnConverter); ok {
+		return converter.ColumnConverter(index)
+	}
+	return driver.DefaultParameterConverter
+}
+
+func (r *connectorRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err == nil {
+		r.count++
+	} else if err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return err
+}
+
+func (r *connectorRows) Close() error {
+	err := r.Rows.Close()
+	r.closeOnce.Do(func() {
+		if r.err == nil {
+			r.err = err
+		}
+		r.closed(r.count, r.err)
+	})
+	return err
+}
+
+func (r *connectorRows) HasNextResultSet() bool {
+	if next, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return next.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *connectorRows) NextResultSet() error {
+	if next, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return next.NextResultSet()
+	}
+	return io.EOF
+}
+
+func (r *connectorRows) ColumnTypeScanType(index int) reflect.Type {
+	if scanType, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return scanType.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+func (r *connectorRows) ColumnTypeDatabaseTypeName(index int) string {
+	if typeName, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return typeName.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *connectorRows) ColumnTypeLength(index int) (int64, bool) {
+	if length, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return length.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *connectorRows) ColumnTypeNullable(index int) (bool, bool) {
+	if nullable, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return nullable.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *connectorRows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	if precision, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return precision.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("db: driver does not support named parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCredentialsRefresh      = time.Minute
+	defaultFileCredentialsInterval = 10 * time.Second
+)
+
+type (
+	// Credentials authenticate a connection, an empty Username keeps DBConfig.Username
+	Credentials struct {
+		Username string
+		Password string
+	}
+
+	// CredentialsProvider supplies the credentials of every new connection, see DBConfig.Credentials.
+	// Credentials is also polled by the credentials_refresh option, providers of remote secrets should cache them.
+	CredentialsProvider interface {
+		Credentials(ctx context.Context) (Credentials, error)
+	}
+
+	// CredentialsProviderFunc is an adapter to allow the use of ordinary functions as CredentialsProvider
+	CredentialsProviderFunc func(ctx context.Context) (Credentials, error)
+
+	staticCredentials Credentials
+
+	envCredentials struct {
+		usernameKey string
+		passwordKey string
+	}
+
+	// fileCredentials rereads the files when their modification time or size changes
+	fileCredentials struct {
+		usernamePath string
+		passwordPath string
+		interval     time.Duration
+
+		mutex       sync.Mutex
+		checkedAt   time.Time
+		usernameMod fileVersion
+		passwordMod fileVersion
+		credentials Credentials
+	}
+
+	fileVersion struct {
+		modTime time.Time
+		size    int64
+	}
+)
+
+func (f CredentialsProviderFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+// StaticCredentials always returns username and password
+func StaticCredentials(username, password string) CredentialsProvider {
+	return staticCredentials{Username: username, Password: password}
+}
+
+func (c staticCredentials) Credentials(context.Context) (Credentials, error) {
+	return Credentials(c), nil
+}
+
+// EnvCredentials reads the username and password from the environment variables on every call,
+// an empty usernameKey keeps DBConfig.Username
+func EnvCredentials(usernameKey, passwordKey string) CredentialsProvider {
+	return envCredentials{usernameKey: usernameKey, passwordKey: passwordKey}
+}
+
+func (c envCredentials) Credentials(context.Context) (Credentials, error) {
+	password, ok := os.LookupEnv(c.passwordKey)
+	if !ok {
+		return Credentials{}, fmt.Errorf("db: environment variable %s is not set", c.passwordKey)
+	}
+	credentials := Credentials{Password: password}
+	if c.usernameKey != "" {
+		credentials.Username = os.Getenv(c.usernameKey)
+	}
+	return credentials, nil
+}
+
+// FileCredentials reads the username and password from files, e.g. a mounted Kubernetes or Vault agent secret.
+// The files are checked for changes at most once per interval (default 10s), trailing newlines are trimmed
+// and an empty usernamePath keeps DBConfig.Username.
+func FileCredentials(usernamePath, passwordPath string, interval time.Duration) CredentialsProvider {
+	if interval <= 0 {
+		interval = defaultFileCredentialsInterval
+	}
+	return &fileCredentials{usernamePath: usernamePath, passwordPath: passwordPath, interval: interval}
+}
+
+func (c *fileCredentials) Credentials(context.Context) (Credentials, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.checkedAt.IsZero() && time.Since(c.checkedAt) < c.interval {
+		return c.credentials, nil
+	}
+
+	credentials := c.credentials
+	if c.usernamePath != "" {
+		username, err := readCredentialsFile(c.usernamePath, &c.usernameMod, credentials.Username)
+		if err != nil {
+			return Credentials{}, err
+		}
+		credentials.Username = username
+	}
+	password, err := readCredentialsFile(c.passwordPath, &c.passwordMod, credentials.Password)
+	if err != nil {
+		return Credentials{}, err
+	}
+	credentials.Password = password
+	c.credentials, c.checkedAt = credentials, time.Now()
+	return credentials, nil
+}
+
+// readCredentialsFile returns the content of path, or current when the file did not change since version
+func readCredentialsFile(path string, version *fileVersion, current string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.ModTime().Equal(version.modTime) && info.Size() == version.size {
+		return current, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	*version = fileVersion{modTime: info.ModTime(), size: info.Size()}
+	return string(bytes.TrimRight(content, "\r\n")), nil
+}
@@ -1,14 +1,58 @@
 package db
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+)
 
 // DBHelper is helper of DB
 type DBHelper interface {
 	Open() *sql.DB
 	Close() error
 	Begin() (*sql.Tx, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
 	Commit(tx *sql.Tx) error
 	Rollback(tx *sql.Tx) error
+	WithTx(ctx context.Context, opts *sql.TxOptions, fn TxFunc) error
+	WithTxContext(ctx context.Context, opts *sql.TxOptions, fn TxContextFunc) error
+	QueryContext(ctx context.Context, statement string, agruments ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, statement string, agruments ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, statement string, agruments ...interface{}) (sql.Result, error)
 	QueryRowsPaging(statement string, offset, limit uint32, agruments []interface{}) (*sql.Rows, error)
 	QueryRowPaging(statement string, offset, limit uint32, agruments []interface{}) *sql.Row
+	QueryRowsPagingContext(ctx context.Context, statement string, offset, limit uint32, agruments []interface{}) (*sql.Rows, error)
+	QueryRowPagingContext(ctx context.Context, statement string, offset, limit uint32, agruments []interface{}) *sql.Row
+	// QueryRowsKeyset seeks the rows after keyset.After, see KeysetQuery
+	QueryRowsKeyset(statement string, keyset Keyset, limit uint32, agruments []interface{}) (*sql.Rows, error)
+	QueryRowsKeysetContext(ctx context.Context, statement string, keyset Keyset, limit uint32, agruments []interface{}) (*sql.Rows, error)
+	// QueryCountContext counts the rows of statement, see CountQuery
+	QueryCountContext(ctx context.Context, statement string, agruments []interface{}) (int64, error)
+	Dialect() Dialect
+	// Ready reports whether the database was reached, see the lazy_connect option
+	Ready() bool
+	// HealthCheck pings the database and reports pool saturation
+	HealthCheck(ctx context.Context) error
+	// QueryStats returns the per statement statistics, see the query_stats option
+	QueryStats() []QueryStat
+	ResetQueryStats()
+}
+
+// NewDBHelperWithDB wraps an opened pool. Its rows do not report their closing, so the query timeout is
+// released when it elapses and the spans of queries finish once they return.
+//
+// Deprecated: use NewDBHelperWithConnector, which releases the timeout when the rows are closed.
+func NewDBHelperWithDB(db *sql.DB, dialect Dialect, opts ...DBOption) DBHelper {
+	helper := newBaseDBHelper(dialect.Name()+"DBHelper", db, dialect, DBConfig{}, opts)
+	helper.unhookedRows = true
+	helper.ready = 1
+	return helper
+}
+
+// NewDBHelperWithConnector creates an instance on a pool of the connections opened by connector,
+// e.g. a driver connector configured in code
+func NewDBHelperWithConnector(connector driver.Connector, dialect Dialect, opts ...DBOption) DBHelper {
+	helper := newBaseDBHelper(dialect.Name()+"DBHelper", sql.OpenDB(wrapConnector(connector)), dialect, DBConfig{}, opts)
+	helper.ready = 1
+	return helper
 }
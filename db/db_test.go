@@ -0,0 +1,32 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"go-core/db"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestNewDBHelperWithDB(t *testing.T) {
+	pool, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	helper := db.NewDBHelperWithDB(pool, db.SQLiteDialect, db.DBOption{Key: "query_timeout", Value: time.Second})
+	t.Cleanup(func() {
+		_ = helper.Close()
+	})
+
+	ids, err := db.SelectAll[int64](context.Background(), helper, "SELECT 1 UNION ALL SELECT 2")
+	if err != nil || len(ids) != 2 {
+		t.Errorf("SelectAll() = %v, %v", ids, err)
+	}
+	var id int64
+	if err := helper.QueryRowContext(context.Background(), "SELECT 3").Scan(&id); err != nil || id != 3 {
+		t.Errorf("QueryRowContext() = %d, %v", id, err)
+	}
+}
@@ -0,0 +1,70 @@
+// Package dbtest runs repository tests without an external database: a DBHelper on an in-memory
+// SQLite database (cgo), a transaction rolled back when the test ends around any helper, or a fake
+// driver returning stubbed results. Every helper records the executed statements for assertions.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"go-core/db"
+
+	// registers the sqlite3 driver used by NewSQLite
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// NewSQLite returns a helper on a private in-memory SQLite database wrapped in a rolled back transaction,
+// the schema is usually created with the returned helper or db/migrate
+func NewSQLite(t testing.TB, opts ...db.DBOption) (db.DBHelper, *Recorder) {
+	t.Helper()
+	helper, err := db.OpenSQLiteDBHelper(context.Background(), db.DBConfig{Database: ":memory:"}, opts...)
+	if err != nil {
+		t.Fatalf("dbtest: open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = helper.Close()
+	})
+	return WithRollback(t, helper, opts...)
+}
+
+// WithRollback returns a helper running every statement in a single transaction of helper which is
+// rolled back when the test ends. Transactions begun on the returned helper become savepoints, its pool
+// holds a single connection like the transaction so rows have to be closed before the next statement.
+func WithRollback(t testing.TB, helper db.DBHelper, opts ...db.DBOption) (db.DBHelper, *Recorder) {
+	t.Helper()
+	tx, err := helper.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("dbtest: begin: %v", err)
+	}
+	recorder := &Recorder{}
+	rollback := db.NewDBHelperWithConnector(&connector{
+		recorder: recorder,
+		backend:  &txBackend{tx: tx, dialect: helper.Dialect()},
+	}, helper.Dialect(), withoutTracing(opts)...)
+	// the savepoint depth is shared, concurrent connections would interleave their savepoints
+	rollback.Open().SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = rollback.Close()
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			t.Errorf("dbtest: rollback: %v", err)
+		}
+	})
+	return rollback, recorder
+}
+
+// NewFake returns a helper whose statements are recorded and answered by the stubs of the returned Fake
+func NewFake(t testing.TB, dialect db.Dialect, opts ...db.DBOption) (db.DBHelper, *Fake) {
+	t.Helper()
+	fake := &Fake{}
+	helper := db.NewDBHelperWithConnector(&connector{recorder: &fake.Recorder, backend: fake}, dialect, withoutTracing(opts)...)
+	t.Cleanup(func() {
+		_ = helper.Close()
+	})
+	return helper, fake
+}
+
+// withoutTracing disables the spans unless the options enable them
+func withoutTracing(opts []db.DBOption) []db.DBOption {
+	return append([]db.DBOption{{Key: "tracing", Value: false}}, opts...)
+}
@@ -0,0 +1,192 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+)
+
+type (
+	// backend executes the statements of the dbtest connections
+	backend interface {
+		exec(ctx context.Context, query string, args []interface{}) (driver.Result, error)
+		query(ctx context.Context, query string, args []interface{}) (driver.Rows, error)
+		begin(ctx context.Context) error
+		commit() error
+		rollback() error
+	}
+
+	connector struct {
+		recorder *Recorder
+		backend  backend
+	}
+
+	conn struct {
+		connector *connector
+	}
+
+	stmt struct {
+		conn  *conn
+		query string
+	}
+
+	tx struct {
+		conn *conn
+	}
+
+	// bufferedRows holds a whole result set so the shared transaction is free for the next statement
+	bufferedRows struct {
+		columns []string
+		values  [][]driver.Value
+		next    int
+	}
+)
+
+func (c *connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{connector: c}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c
+}
+
+// Open implements driver.Driver, the pools are created by sql.OpenDB so the name is unused
+func (c *connector) Open(string) (driver.Conn, error) {
+	return &conn{connector: c}, nil
+}
+
+// CheckNamedValue keeps the arguments as given, they are converted by the backend
+func (c *conn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	c.connector.recorder.record("BEGIN", nil)
+	if err := c.connector.backend.begin(ctx); err != nil {
+		return nil, err
+	}
+	return &tx{conn: c}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	values := namedValues(args)
+	c.connector.recorder.record(query, values)
+	return c.connector.backend.exec(ctx, query, values)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	values := namedValues(args)
+	c.connector.recorder.record(query, values)
+	return c.connector.backend.query(ctx, query, values)
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput returns -1, the arguments are checked by the backend
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), valuesToNamed(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), valuesToNamed(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func (t *tx) Commit() error {
+	t.conn.connector.recorder.record("COMMIT", nil)
+	return t.conn.connector.backend.commit()
+}
+
+func (t *tx) Rollback() error {
+	t.conn.connector.recorder.record("ROLLBACK", nil)
+	return t.conn.connector.backend.rollback()
+}
+
+func (r *bufferedRows) Columns() []string {
+	return r.columns
+}
+
+func (r *bufferedRows) Close() error {
+	return nil
+}
+
+func (r *bufferedRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
+
+// bufferRows reads rows entirely
+func bufferRows(rows *sql.Rows) (driver.Rows, error) {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &bufferedRows{columns: columns}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		row := make([]driver.Value, len(columns))
+		for i, value := range values {
+			row[i] = value
+		}
+		result.values = append(result.values, row)
+	}
+	return result, rows.Err()
+}
+
+func namedValues(args []driver.NamedValue) []interface{} {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			values[i] = sql.Named(arg.Name, arg.Value)
+		} else {
+			values[i] = arg.Value
+		}
+	}
+	return values
+}
+
+func valuesToNamed(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
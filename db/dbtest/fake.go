@@ -0,0 +1,108 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+)
+
+type (
+	// Fake answers the statements of a NewFake helper with stubs, the last matching stub wins.
+	// Unmatched queries return no rows and unmatched statements affect no rows.
+	Fake struct {
+		Recorder
+
+		stubsMutex sync.Mutex
+		stubs      []stub
+	}
+
+	stub struct {
+		query        string
+		columns      []string
+		rows         [][]interface{}
+		rowsAffected int64
+		lastInsertID int64
+		err          error
+	}
+
+	fakeResult struct {
+		rowsAffected int64
+		lastInsertID int64
+	}
+)
+
+// StubQuery returns rows with columns to the queries containing query
+func (f *Fake) StubQuery(query string, columns []string, rows ...[]interface{}) {
+	f.addStub(stub{query: query, columns: columns, rows: rows})
+}
+
+// StubExec reports rowsAffected and lastInsertID to the statements containing query
+func (f *Fake) StubExec(query string, rowsAffected, lastInsertID int64) {
+	f.addStub(stub{query: query, rowsAffected: rowsAffected, lastInsertID: lastInsertID})
+}
+
+// StubError fails the statements containing query with err
+func (f *Fake) StubError(query string, err error) {
+	f.addStub(stub{query: query, err: err})
+}
+
+func (f *Fake) addStub(item stub) {
+	f.stubsMutex.Lock()
+	defer f.stubsMutex.Unlock()
+	f.stubs = append(f.stubs, item)
+}
+
+func (f *Fake) match(query string) stub {
+	f.stubsMutex.Lock()
+	defer f.stubsMutex.Unlock()
+	for i := len(f.stubs) - 1; i >= 0; i-- {
+		if matches(query, f.stubs[i].query) {
+			return f.stubs[i]
+		}
+	}
+	return stub{}
+}
+
+func (f *Fake) exec(_ context.Context, query string, _ []interface{}) (driver.Result, error) {
+	item := f.match(query)
+	if item.err != nil {
+		return nil, item.err
+	}
+	return fakeResult{rowsAffected: item.rowsAffected, lastInsertID: item.lastInsertID}, nil
+}
+
+func (f *Fake) query(_ context.Context, query string, _ []interface{}) (driver.Rows, error) {
+	item := f.match(query)
+	if item.err != nil {
+		return nil, item.err
+	}
+	rows := &bufferedRows{columns: item.columns}
+	for _, row := range item.rows {
+		values := make([]driver.Value, len(row))
+		for i, value := range row {
+			values[i] = value
+		}
+		rows.values = append(rows.values, values)
+	}
+	return rows, nil
+}
+
+func (f *Fake) begin(context.Context) error {
+	return nil
+}
+
+func (f *Fake) commit() error {
+	return nil
+}
+
+func (f *Fake) rollback() error {
+	return nil
+}
+
+func (r fakeResult) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+func (r fakeResult) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
@@ -0,0 +1,131 @@
+package dbtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"go-core/db"
+	"go-core/db/sqlb"
+
+	"gopkg.in/yaml.v2"
+)
+
+// tableRows are the fixture rows of a table
+type tableRows struct {
+	table string
+	rows  []map[string]interface{}
+}
+
+// LoadFixtures inserts the rows of YAML (.yml, .yaml) or JSON files. A file maps table names to lists of
+// rows keyed by column, tables are inserted in file order so parents can precede children:
+//
+//	users:
+//	  - id: 1
+//	    email: a@example.com
+//	orders:
+//	  - id: 10
+//	    user_id: 1
+func LoadFixtures(ctx context.Context, helper db.DBHelper, paths ...string) error {
+	return LoadFixturesFS(ctx, helper, os.DirFS("."), paths...)
+}
+
+// LoadFixturesFS is LoadFixtures reading from fsys, e.g. an embed.FS
+func LoadFixturesFS(ctx context.Context, helper db.DBHelper, fsys fs.FS, paths ...string) error {
+	for _, name := range paths {
+		content, err := fs.ReadFile(fsys, strings.TrimPrefix(path.Clean(name), "./"))
+		if err != nil {
+			return err
+		}
+		var tables []tableRows
+		switch path.Ext(name) {
+		case ".yml", ".yaml":
+			tables, err = decodeYAML(content)
+		case ".json":
+			tables, err = decodeJSON(content)
+		default:
+			err = fmt.Errorf("unsupported fixture format")
+		}
+		if err != nil {
+			return fmt.Errorf("fixture %s: %w", name, err)
+		}
+		for _, table := range tables {
+			for _, row := range table.rows {
+				statement, agruments := sqlb.Insert(table.table).SetMap(row).Build(helper.Dialect())
+				if _, err := helper.ExecContext(ctx, statement, agruments...); err != nil {
+					return fmt.Errorf("fixture %s, table %s: %w", name, table.table, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func decodeYAML(content []byte) ([]tableRows, error) {
+	var document yaml.MapSlice
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	tables := make([]tableRows, 0, len(document))
+	for _, item := range document {
+		data, err := yaml.Marshal(item.Value)
+		if err != nil {
+			return nil, err
+		}
+		var rows []map[string]interface{}
+		if err := yaml.Unmarshal(data, &rows); err != nil {
+			return nil, err
+		}
+		tables = append(tables, tableRows{table: fmt.Sprint(item.Key), rows: rows})
+	}
+	return tables, nil
+}
+
+// decodeJSON reads the top level object token by token to keep the table order
+func decodeJSON(content []byte) ([]tableRows, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("fixture must be an object of tables")
+	}
+	var tables []tableRows
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var rows []map[string]interface{}
+		if err := decoder.Decode(&rows); err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			for column, value := range row {
+				row[column] = jsonValue(value)
+			}
+		}
+		tables = append(tables, tableRows{table: fmt.Sprint(token), rows: rows})
+	}
+	return tables, nil
+}
+
+// jsonValue converts numbers to int64 when they are integers and float64 otherwise,
+// objects and arrays are stored as their JSON text
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if integer, err := v.Int64(); err == nil {
+			return integer
+		}
+		float, _ := v.Float64()
+		return float
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return value
+}
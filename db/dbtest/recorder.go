@@ -0,0 +1,123 @@
+package dbtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type (
+	// Statement is an executed statement, transactions are recorded as BEGIN, COMMIT and ROLLBACK
+	Statement struct {
+		Query string
+		Args  []interface{}
+	}
+
+	// Recorder records the statements executed through a dbtest helper
+	Recorder struct {
+		mutex      sync.Mutex
+		statements []Statement
+	}
+)
+
+func (r *Recorder) record(query string, args []interface{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.statements = append(r.statements, Statement{Query: query, Args: args})
+}
+
+// Statements returns the executed statements in order
+func (r *Recorder) Statements() []Statement {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	statements := make([]Statement, len(r.statements))
+	copy(statements, r.statements)
+	return statements
+}
+
+// Reset forgets the recorded statements, e.g. after loading fixtures
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.statements = nil
+}
+
+// Matching returns the statements containing query, compared case insensitively with collapsed whitespace
+func (r *Recorder) Matching(query string) []Statement {
+	var result []Statement
+	for _, statement := range r.Statements() {
+		if matches(statement.Query, query) {
+			result = append(result, statement)
+		}
+	}
+	return result
+}
+
+// AssertExecuted fails the test unless a statement containing query was executed, with exactly args when given
+func (r *Recorder) AssertExecuted(t testing.TB, query string, args ...interface{}) bool {
+	t.Helper()
+	for _, statement := range r.Matching(query) {
+		if len(args) == 0 || equalArgs(statement.Args, args) {
+			return true
+		}
+	}
+	if len(args) == 0 {
+		t.Errorf("dbtest: no statement matching %q was executed\n%s", query, r)
+	} else {
+		t.Errorf("dbtest: no statement matching %q was executed with %v\n%s", query, args, r)
+	}
+	return false
+}
+
+// AssertNotExecuted fails the test when a statement containing query was executed
+func (r *Recorder) AssertNotExecuted(t testing.TB, query string) bool {
+	t.Helper()
+	if matching := r.Matching(query); len(matching) > 0 {
+		t.Errorf("dbtest: %d statements matching %q were executed\n%s", len(matching), query, r)
+		return false
+	}
+	return true
+}
+
+// AssertCount fails the test unless count statements containing query were executed
+func (r *Recorder) AssertCount(t testing.TB, query string, count int) bool {
+	t.Helper()
+	if matching := r.Matching(query); len(matching) != count {
+		t.Errorf("dbtest: %d statements matching %q were executed, expected %d\n%s", len(matching), query, count, r)
+		return false
+	}
+	return true
+}
+
+// String lists the executed statements
+func (r *Recorder) String() string {
+	builder := strings.Builder{}
+	builder.WriteString("executed statements:")
+	for i, statement := range r.Statements() {
+		builder.WriteString(fmt.Sprintf("\n  %d: %s %v", i+1, collapse(statement.Query), statement.Args))
+	}
+	return builder.String()
+}
+
+func matches(statement, query string) bool {
+	return strings.Contains(strings.ToLower(collapse(statement)), strings.ToLower(collapse(query)))
+}
+
+func collapse(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// equalArgs compares the arguments, values of different types are equal when they print the same (int and int64)
+func equalArgs(actual, expected []interface{}) bool {
+	if len(actual) != len(expected) {
+		return false
+	}
+	for i := range actual {
+		if !reflect.DeepEqual(actual[i], expected[i]) && fmt.Sprint(actual[i]) != fmt.Sprint(expected[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+
+	"go-core/db"
+)
+
+// txBackend runs every statement in tx, nested transactions are savepoints of it
+type txBackend struct {
+	mutex   sync.Mutex
+	tx      *sql.Tx
+	dialect db.Dialect
+	depth   int
+}
+
+func (b *txBackend) exec(ctx context.Context, query string, args []interface{}) (driver.Result, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.tx.ExecContext(ctx, query, args...)
+}
+
+func (b *txBackend) query(ctx context.Context, query string, args []interface{}) (driver.Rows, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	rows, err := b.tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return bufferRows(rows)
+}
+
+func (b *txBackend) begin(ctx context.Context) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.depth++
+	_, err := b.tx.ExecContext(ctx, b.dialect.Savepoint(b.savepoint()))
+	if err != nil {
+		b.depth--
+	}
+	return err
+}
+
+func (b *txBackend) commit() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	defer func() {
+		b.depth--
+	}()
+	if release := b.dialect.ReleaseSavepoint(b.savepoint()); release != "" {
+		_, err := b.tx.Exec(release)
+		return err
+	}
+	return nil
+}
+
+func (b *txBackend) rollback() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	defer func() {
+		b.depth--
+	}()
+	_, err := b.tx.Exec(b.dialect.RollbackToSavepoint(b.savepoint()))
+	return err
+}
+
+func (b *txBackend) savepoint() string {
+	return fmt.Sprintf("dbtest_sp_%d", b.depth)
+}
@@ -0,0 +1,231 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect owns the SQL differences between database engines
+type Dialect interface {
+	// Name returns the engine name, e.g. "postgres"
+	Name() string
+	// Placeholder returns the bind parameter of the 1-based argument index
+	Placeholder(index int) string
+	// Quote quotes an identifier, every part of a qualified name is quoted
+	Quote(identifier string) string
+	// Paging appends the offset/limit clause to statement and their values to agruments
+	Paging(statement string, offset, limit uint32, agruments []interface{}) (string, []interface{})
+	// Limit appends a limit only clause to statement and its value to agruments
+	Limit(statement string, limit uint32, agruments []interface{}) (string, []interface{})
+	// IsRetryable reports whether err is a serialization failure or a deadlock
+	IsRetryable(err error) bool
+	// Savepoint, RollbackToSavepoint and ReleaseSavepoint return the savepoint statements,
+	// ReleaseSavepoint returns an empty string when the engine has no release statement
+	Savepoint(name string) string
+	RollbackToSavepoint(name string) string
+	ReleaseSavepoint(name string) string
+}
+
+var (
+	// PostgresDialect renders $n placeholders and OFFSET/LIMIT
+	PostgresDialect Dialect = postgresDialect{}
+	// OracleDialect renders :n placeholders and OFFSET/FETCH NEXT (12c+)
+	OracleDialect Dialect = oracleDialect{}
+	// SQLServerDialect renders @pn placeholders and OFFSET/FETCH NEXT (2012+)
+	SQLServerDialect Dialect = sqlServerDialect{}
+	// MySQLDialect renders ? placeholders and LIMIT/OFFSET, it also covers MariaDB
+	MySQLDialect Dialect = mysqlDialect{}
+	// SQLiteDialect renders ? placeholders and LIMIT/OFFSET
+	SQLiteDialect Dialect = sqliteDialect{}
+)
+
+type (
+	postgresDialect  struct{}
+	oracleDialect    struct{}
+	sqlServerDialect struct{}
+	mysqlDialect     struct{}
+	sqliteDialect    struct{}
+)
+
+func quoteParts(identifier, open, close string) string {
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		parts[i] = open + strings.ReplaceAll(part, close, close+close) + close
+	}
+	return strings.Join(parts, ".")
+}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (postgresDialect) Placeholder(index int) string {
+	return fmt.Sprintf("$%d", index)
+}
+
+func (postgresDialect) Quote(identifier string) string {
+	return quoteParts(identifier, `"`, `"`)
+}
+
+func (d postgresDialect) Paging(statement string, offset, limit uint32, agruments []interface{}) (string, []interface{}) {
+	queryBuilder := strings.Builder{}
+	queryBuilder.WriteString(statement)
+	queryBuilder.WriteString(fmt.Sprintf(" OFFSET %s LIMIT %s", d.Placeholder(len(agruments)+1), d.Placeholder(len(agruments)+2)))
+	return queryBuilder.String(), append(agruments, offset, limit)
+}
+
+func (d postgresDialect) Limit(statement string, limit uint32, agruments []interface{}) (string, []interface{}) {
+	return statement + " LIMIT " + d.Placeholder(len(agruments)+1), append(agruments, limit)
+}
+
+func (postgresDialect) Savepoint(name string) string {
+	return "SAVEPOINT " + name
+}
+
+func (postgresDialect) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+
+func (postgresDialect) ReleaseSavepoint(name string) string {
+	return "RELEASE SAVEPOINT " + name
+}
+
+func (oracleDialect) Name() string {
+	return "oracle"
+}
+
+func (oracleDialect) Placeholder(index int) string {
+	return fmt.Sprintf(":%d", index)
+}
+
+func (oracleDialect) Quote(identifier string) string {
+	return quoteParts(identifier, `"`, `"`)
+}
+
+func (d oracleDialect) Paging(statement string, offset, limit uint32, agruments []interface{}) (string, []interface{}) {
+	queryBuilder := strings.Builder{}
+	queryBuilder.WriteString(statement)
+	queryBuilder.WriteString(fmt.Sprintf(" OFFSET %s ROWS FETCH NEXT %s ROWS ONLY", d.Placeholder(len(agruments)+1), d.Placeholder(len(agruments)+2)))
+	return queryBuilder.String(), append(agruments, offset, limit)
+}
+
+func (d oracleDialect) Limit(statement string, limit uint32, agruments []interface{}) (string, []interface{}) {
+	return statement + " FETCH NEXT " + d.Placeholder(len(agruments)+1) + " ROWS ONLY", append(agruments, limit)
+}
+
+func (oracleDialect) Savepoint(name string) string {
+	return "SAVEPOINT " + name
+}
+
+func (oracleDialect) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+
+func (oracleDialect) ReleaseSavepoint(name string) string {
+	return ""
+}
+
+func (sqlServerDialect) Name() string {
+	return "sqlserver"
+}
+
+func (sqlServerDialect) Placeholder(index int) string {
+	return fmt.Sprintf("@p%d", index)
+}
+
+func (sqlServerDialect) Quote(identifier string) string {
+	return quoteParts(identifier, "[", "]")
+}
+
+// Paging adds ORDER BY (SELECT NULL) when the statement is not ordered since OFFSET requires ORDER BY
+func (d sqlServerDialect) Paging(statement string, offset, limit uint32, agruments []interface{}) (string, []interface{}) {
+	queryBuilder := strings.Builder{}
+	queryBuilder.WriteString(statement)
+	if !hasOrderBy(statement) {
+		queryBuilder.WriteString(" ORDER BY (SELECT NULL)")
+	}
+	queryBuilder.WriteString(fmt.Sprintf(" OFFSET %s ROWS FETCH NEXT %s ROWS ONLY", d.Placeholder(len(agruments)+1), d.Placeholder(len(agruments)+2)))
+	return queryBuilder.String(), append(agruments, offset, limit)
+}
+
+func (d sqlServerDialect) Limit(statement string, limit uint32, agruments []interface{}) (string, []interface{}) {
+	return d.Paging(statement, 0, limit, agruments)
+}
+
+func (sqlServerDialect) Savepoint(name string) string {
+	return "SAVE TRANSACTION " + name
+}
+
+func (sqlServerDialect) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TRANSACTION " + name
+}
+
+func (sqlServerDialect) ReleaseSavepoint(name string) string {
+	return ""
+}
+
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (mysqlDialect) Placeholder(index int) string {
+	return "?"
+}
+
+func (mysqlDialect) Quote(identifier string) string {
+	return quoteParts(identifier, "`", "`")
+}
+
+// Paging renders LIMIT ? OFFSET ?, the limit is bound first
+func (mysqlDialect) Paging(statement string, offset, limit uint32, agruments []interface{}) (string, []interface{}) {
+	return statement + " LIMIT ? OFFSET ?", append(agruments, limit, offset)
+}
+
+func (mysqlDialect) Limit(statement string, limit uint32, agruments []interface{}) (string, []interface{}) {
+	return statement + " LIMIT ?", append(agruments, limit)
+}
+
+func (mysqlDialect) Savepoint(name string) string {
+	return "SAVEPOINT " + name
+}
+
+func (mysqlDialect) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+
+func (mysqlDialect) ReleaseSavepoint(name string) string {
+	return "RELEASE SAVEPOINT " + name
+}
+
+func (sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) Placeholder(index int) string {
+	return "?"
+}
+
+func (sqliteDialect) Quote(identifier string) string {
+	return quoteParts(identifier, `"`, `"`)
+}
+
+// Paging renders LIMIT ? OFFSET ?, the limit is bound first
+func (sqliteDialect) Paging(statement string, offset, limit uint32, agruments []interface{}) (string, []interface{}) {
+	return statement + " LIMIT ? OFFSET ?", append(agruments, limit, offset)
+}
+
+func (sqliteDialect) Limit(statement string, limit uint32, agruments []interface{}) (string, []interface{}) {
+	return statement + " LIMIT ?", append(agruments, limit)
+}
+
+func (sqliteDialect) Savepoint(name string) string {
+	return "SAVEPOINT " + name
+}
+
+func (sqliteDialect) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+
+func (sqliteDialect) ReleaseSavepoint(name string) string {
+	return "RELEASE SAVEPOINT " + name
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"errors"
+	"strings"
+)
+
+type (
+	// sqlStateError is implemented by postgres drivers (lib/pq, pgx)
+	sqlStateError interface {
+		SQLState() string
+	}
+
+	// sqlErrorNumber is implemented by SQL Server drivers (go-mssqldb)
+	sqlErrorNumber interface {
+		SQLErrorNumber() int32
+	}
+)
+
+// errorSQLState returns the SQLSTATE code of err when the driver exposes it
+func errorSQLState(err error) string {
+	var stateErr sqlStateError
+	if errors.As(err, &stateErr) {
+		return stateErr.SQLState()
+	}
+	return ""
+}
+
+// errorContains reports whether the message of err contains any of the fragments, case insensitively
+func errorContains(err error, fragments ...string) bool {
+	message := strings.ToLower(err.Error())
+	for _, fragment := range fragments {
+		if strings.Contains(message, strings.ToLower(fragment)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (postgresDialect) IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch errorSQLState(err) {
+	// serialization_failure, deadlock_detected
+	case "40001", "40P01":
+		return true
+	}
+	return errorContains(err, "could not serialize access", "deadlock detected")
+}
+
+func (oracleDialect) IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	// can't serialize access for this transaction, deadlock detected while waiting for resource
+	return errorContains(err, "ORA-08177", "ORA-00060")
+}
+
+func (sqlServerDialect) IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var numberErr sqlErrorNumber
+	if errors.As(err, &numberErr) {
+		switch numberErr.SQLErrorNumber() {
+		// deadlock victim, snapshot isolation update conflict
+		case 1205, 3960:
+			return true
+		}
+	}
+	return errorContains(err, "deadlock victim", "snapshot isolation transaction aborted")
+}
+
+func (mysqlDialect) IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	// go-sql-driver/mysql formats the error number into the message: 1213 deadlock, 1205 lock wait timeout
+	return errorContains(err, "Error 1213", "Error 1205", "Deadlock found", "Lock wait timeout exceeded")
+}
+
+func (sqliteDialect) IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	// SQLITE_BUSY and SQLITE_LOCKED, the database file is locked by another connection
+	return errorContains(err, "database is locked", "database table is locked")
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const defaultHealthCheckTimeout = time.Second
+
+// PoolSaturatedError is returned by HealthCheck when every connection is in use and callers had to wait
+type PoolSaturatedError struct {
+	InUse        int
+	MaxOpen      int
+	WaitCount    int64
+	WaitDuration time.Duration
+}
+
+func (e *PoolSaturatedError) Error() string {
+	return fmt.Sprintf("connection pool saturated: %d/%d connections in use, %d waits since the last check, %s waited in total",
+		e.InUse, e.MaxOpen, e.WaitCount, e.WaitDuration)
+}
+
+// HealthCheck pings the database within health_check_timeout and fails with *PoolSaturatedError when
+// every connection is in use and new waits happened since the previous check. A successful ping marks
+// a lazily connecting helper as ready.
+func (h *baseDBHelper) HealthCheck(ctx context.Context) (err error) {
+	span := h.startSpan(ctx, "HealthCheck", "")
+	defer func() {
+		finishSpan(span, nil, err)
+	}()
+
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline && h.healthCheckTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.healthCheckTimeout)
+		defer cancel()
+	}
+	if err := h.db.PingContext(ctx); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&h.ready, 1)
+
+	stats := h.db.Stats()
+	waits := stats.WaitCount - atomic.SwapInt64(&h.lastWaitCount, stats.WaitCount)
+	if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections && waits > 0 {
+		return &PoolSaturatedError{
+			InUse:        stats.InUse,
+			MaxOpen:      stats.MaxOpenConnections,
+			WaitCount:    waits,
+			WaitDuration: stats.WaitDuration,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// DBOption represents db helper option
+//
+//	query_timeout    time.Duration, default timeout of queries whose context has no deadline
+//	tx_max_retries   int, retries of WithTx on serialization failures and deadlocks, default 3
+//	tx_retry_backoff time.Duration, base of the exponential retry backoff, default 50ms
+//	tracing          bool, emit a client span per operation, default true
+//	connect_retry    RetryPolicy, retries the initial connection of the Open constructors, default a single attempt
+//	lazy_connect     bool, Open constructors return before the database is reachable and connect in the background
+//	health_check_timeout time.Duration, timeout of the HealthCheck ping when ctx has no deadline, default 1s
+//	slow_query_threshold time.Duration, logs the statements running longer, zero disables the log
+//	slow_query_unnamed_arguments bool, logs the arguments whose column is unknown instead of redacting them, default false
+//	query_stats      bool, aggregate per statement counts and latencies for QueryStats, default true
+//	credentials_refresh time.Duration, polling of DBConfig.Credentials recycling the pooled connections after a rotation, default 1m
+//	soft_delete_column string, e.g. "deleted_at", paging queries skip the rows where it is set unless ctx is IncludeDeleted
+type DBOption struct {
+	Key   string
+	Value interface{}
+}
+
+// baseDBHelper implements DBHelper on top of a dialect, engines embed it
+type baseDBHelper struct {
+	name         string
+	db           *sql.DB
+	dialect      Dialect
+	queryTimeout time.Duration
+
+	txMaxRetries   int
+	txRetryBackoff time.Duration
+
+	tracing bool
+
+	connectRetry RetryPolicy
+	lazyConnect  bool
+	ready        int32
+
+	credentialsRefresh time.Duration
+
+	healthCheckTimeout time.Duration
+	lastWaitCount      int64
+
+	slowQueryThreshold time.Duration
+	// slowQueryUnnamedArguments logs the argN arguments, which the masking rules cannot match
+	slowQueryUnnamedArguments bool
+	queryStats                *queryStats
+
+	softDeleteColumn string
+
+	// unhookedRows is set for a pool not opened through the db connector, its rows never call the close hook
+	unhookedRows bool
+
+	config    DBConfig
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+func newBaseDBHelper(name string, db *sql.DB, dialect Dialect, cfg DBConfig, opts []DBOption) *baseDBHelper {
+	helper := &baseDBHelper{
+		name:               name,
+		db:                 db,
+		dialect:            dialect,
+		txMaxRetries:       defaultTxMaxRetries,
+		txRetryBackoff:     defaultTxRetryBackoff,
+		tracing:            true,
+		connectRetry:       RetryPolicy{MaxAttempts: 1},
+		healthCheckTimeout: defaultHealthCheckTimeout,
+		credentialsRefresh: defaultCredentialsRefresh,
+		queryStats:         newQueryStats(),
+		config:             cfg,
+		stop:               make(chan struct{}),
+	}
+	for _, item := range cfg.options(opts) {
+		switch item.Key {
+		case "query_timeout":
+			helper.queryTimeout = item.Value.(time.Duration)
+		case "tx_max_retries":
+			helper.txMaxRetries = item.Value.(int)
+		case "tx_retry_backoff":
+			helper.txRetryBackoff = item.Value.(time.Duration)
+		case "tracing":
+			helper.tracing = item.Value.(bool)
+		case "connect_retry":
+			helper.connectRetry = item.Value.(RetryPolicy)
+		case "lazy_connect":
+			helper.lazyConnect = item.Value.(bool)
+		case "credentials_refresh":
+			helper.credentialsRefresh = item.Value.(time.Duration)
+		case "health_check_timeout":
+			helper.healthCheckTimeout = item.Value.(time.Duration)
+		case "slow_query_threshold":
+			helper.slowQueryThreshold = item.Value.(time.Duration)
+		case "slow_query_unnamed_arguments":
+			helper.slowQueryUnnamedArguments = item.Value.(bool)
+		case "query_stats":
+			if !item.Value.(bool) {
+				helper.queryStats = nil
+			}
+		case "soft_delete_column":
+			helper.softDeleteColumn = item.Value.(string)
+		}
+	}
+	if cfg.StatsInterval > 0 {
+		go reportStats(db, dialect, cfg, helper.stop)
+	}
+	return helper
+}
+
+// withTimeout applies the default query timeout when ctx has no deadline. The timeout covers
+// the whole statement including row iteration, queries release it when their rows are closed.
+func (h *baseDBHelper) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if h.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, hasDeadline := ctx.Deadline(); hasDeadline {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, h.queryTimeout)
+}
+
+func (h *baseDBHelper) QueryRowsPaging(statement string, offset, limit uint32,
+	agruments []interface{}) (rows *sql.Rows, errQuery error) {
+	return h.QueryRowsPagingContext(context.Background(), statement, offset, limit, agruments)
+}
+
+func (h *baseDBHelper) QueryRowPaging(statement string, offset, limit uint32,
+	agruments []interface{}) (row *sql.Row) {
+	return h.QueryRowPagingContext(context.Background(), statement, offset, limit, agruments)
+}
+
+func (h *baseDBHelper) QueryRowsKeyset(statement string, keyset Keyset, limit uint32,
+	agruments []interface{}) (rows *sql.Rows, errQuery error) {
+	return h.QueryRowsKeysetContext(context.Background(), statement, keyset, limit, agruments)
+}
+
+func (h *baseDBHelper) QueryRowsPagingContext(ctx context.Context, statement string, offset, limit uint32,
+	agruments []interface{}) (rows *sql.Rows, errQuery error) {
+	query, agruments := h.dialect.Paging(h.softDeleteFilter(ctx, statement), offset, limit, agruments)
+	return h.query(ctx, "QueryRowsPagingContext", query, agruments)
+}
+
+func (h *baseDBHelper) QueryRowPagingContext(ctx context.Context, statement string, offset, limit uint32,
+	agruments []interface{}) (row *sql.Row) {
+	query, agruments := h.dialect.Paging(h.softDeleteFilter(ctx, statement), offset, limit, agruments)
+	return h.queryRow(ctx, "QueryRowPagingContext", query, agruments)
+}
+
+func (h *baseDBHelper) QueryRowsKeysetContext(ctx context.Context, statement string, keyset Keyset, limit uint32,
+	agruments []interface{}) (rows *sql.Rows, errQuery error) {
+	query, agruments, errQuery := KeysetQuery(h.dialect, h.softDeleteFilter(ctx, statement), keyset, limit, agruments)
+	if errQuery != nil {
+		return nil, errQuery
+	}
+	return h.query(ctx, "QueryRowsKeysetContext", query, agruments)
+}
+
+// QueryCountContext counts the rows of statement with the soft_delete_column filter of the paging queries
+func (h *baseDBHelper) QueryCountContext(ctx context.Context, statement string, agruments []interface{}) (count int64, err error) {
+	err = h.queryRow(ctx, "QueryCountContext", CountQuery(h.softDeleteFilter(ctx, statement)), agruments).Scan(&count)
+	return count, err
+}
+
+func (h *baseDBHelper) QueryContext(ctx context.Context, statement string, agruments ...interface{}) (*sql.Rows, error) {
+	return h.query(ctx, "QueryContext", statement, agruments)
+}
+
+func (h *baseDBHelper) QueryRowContext(ctx context.Context, statement string, agruments ...interface{}) *sql.Row {
+	return h.queryRow(ctx, "QueryRowContext", statement, agruments)
+}
+
+func (h *baseDBHelper) ExecContext(ctx context.Context, statement string, agruments ...interface{}) (result sql.Result, err error) {
+	span := h.startSpan(ctx, "ExecContext", statement)
+	defer func() {
+		finishSpan(span, result, err)
+	}()
+
+	ctx, cancel := h.withTimeout(ctx)
+	defer cancel()
+	start := time.Now()
+	result, err = h.db.ExecContext(ctx, statement, agruments...)
+	h.observe("ExecContext", statement, agruments, start, err)
+	return result, err
+}
+
+// query finishes the span when the rows are closed, recording the number of rows read
+func (h *baseDBHelper) query(ctx context.Context, method, statement string, agruments []interface{}) (rows *sql.Rows, errQuery error) {
+	span := h.startSpan(ctx, method, statement)
+	ctx, closed := h.rowsContext(ctx, span)
+	start := time.Now()
+	rows, errQuery = h.db.QueryContext(ctx, statement, agruments...)
+	h.observe(method, statement, agruments, start, errQuery)
+	if errQuery != nil {
+		closed(0, errQuery)
+		return nil, errQuery
+	}
+	h.releaseUnhooked(span)
+	return rows, nil
+}
+
+// queryRow finishes the span when the row is scanned
+func (h *baseDBHelper) queryRow(ctx context.Context, method, statement string, agruments []interface{}) *sql.Row {
+	span := h.startSpan(ctx, method, statement)
+	ctx, closed := h.rowsContext(ctx, span)
+	start := time.Now()
+	row := h.db.QueryRowContext(ctx, statement, agruments...)
+	h.observe(method, statement, agruments, start, row.Err())
+	if err := row.Err(); err != nil {
+		closed(0, err)
+	} else {
+		h.releaseUnhooked(span)
+	}
+	return row
+}
+
+// releaseUnhooked finishes span once the query returned when the rows cannot report their closing,
+// the timeout is then released when it elapses
+func (h *baseDBHelper) releaseUnhooked(span opentracing.Span) {
+	if h.unhookedRows {
+		finishSpan(span, nil, nil)
+	}
+}
+
+// rowsContext applies the default timeout to ctx and returns it with a rows close hook releasing the
+// timeout and finishing span, the hook is also returned for queries failing before returning rows
+func (h *baseDBHelper) rowsContext(ctx context.Context, span opentracing.Span) (context.Context, func(count int64, err error)) {
+	ctx, cancel := h.withTimeout(ctx)
+	once := sync.Once{}
+	closed := func(count int64, err error) {
+		once.Do(func() {
+			// rows closed by database/sql when the context is done did not fail while iterating
+			if err == nil {
+				err = ctx.Err()
+			}
+			cancel()
+			finishRowsSpan(span, count, err)
+		})
+	}
+	return withRowsClosed(ctx, closed), closed
+}
+
+func (h *baseDBHelper) Dialect() Dialect {
+	return h.dialect
+}
+
+func (h *baseDBHelper) Open() *sql.DB {
+	return h.db
+}
+
+func (h *baseDBHelper) Close() error {
+	h.closeOnce.Do(func() {
+		close(h.stop)
+	})
+	return h.db.Close()
+}
+
+func (h *baseDBHelper) Begin() (*sql.Tx, error) {
+	return h.BeginTx(context.Background(), nil)
+}
+
+// BeginTx starts a transaction bound to ctx, the default query timeout does not apply
+// since cancelling the context rolls the transaction back
+func (h *baseDBHelper) BeginTx(ctx context.Context, opts *sql.TxOptions) (tx *sql.Tx, err error) {
+	span := h.startSpan(ctx, "BeginTx", "")
+	defer func() {
+		finishSpan(span, nil, err)
+	}()
+
+	return h.db.BeginTx(ctx, opts)
+}
+
+// Commit has no context so its span is a root span, transactions run by WithTx are traced under the caller span
+func (h *baseDBHelper) Commit(tx *sql.Tx) error {
+	return h.commit(context.Background(), tx)
+}
+
+func (h *baseDBHelper) Rollback(tx *sql.Tx) error {
+	return h.rollback(context.Background(), tx)
+}
+
+func (h *baseDBHelper) commit(ctx context.Context, tx *sql.Tx) (err error) {
+	span := h.startSpan(ctx, "Commit", "")
+	defer func() {
+		finishSpan(span, nil, err)
+	}()
+	return tx.Commit()
+}
+
+func (h *baseDBHelper) rollback(ctx context.Context, tx *sql.Tx) (err error) {
+	span := h.startSpan(ctx, "Rollback", "")
+	defer func() {
+		finishSpan(span, nil, err)
+	}()
+	return tx.Rollback()
+}
@@ -0,0 +1,24 @@
+// Package invalidate deletes cache keys when database rows change, driven by the notifications of a db.Listener.
+package invalidate
+
+import (
+	"context"
+
+	"go-core/cache"
+	"go-core/db"
+)
+
+// Keys returns a handler deleting the cache keys returned by keys for the decoded JSON payload, e.g.
+//
+//	listener.Listen("users", invalidate.Keys(helper, func(user UserChanged) []string {
+//		return []string{"user:" + user.ID}
+//	}))
+func Keys[T any](helper cache.CacheHelper, keys func(payload T) []string) db.NotifyFunc {
+	return db.NotifyJSON(func(_ string, payload T) error {
+		affected := keys(payload)
+		if len(affected) == 0 {
+			return nil
+		}
+		return helper.DelMulti(context.Background(), affected...)
+	})
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Keyset describes a seek pagination position. Columns must uniquely order the rows
+// (append the primary key to non unique columns) and must be selected by the statement.
+type Keyset struct {
+	Columns []string
+	// After holds the column values of the last row of the previous page, empty for the first page
+	After      []interface{}
+	Descending bool
+}
+
+// KeysetQuery wraps statement to return the limit rows following keyset.After ordered by keyset.Columns.
+// The statement must not be ordered, the ordering is added on the wrapping query.
+func KeysetQuery(dialect Dialect, statement string, keyset Keyset, limit uint32, agruments []interface{}) (string, []interface{}, error) {
+	if len(keyset.Columns) == 0 {
+		return "", nil, fmt.Errorf("keyset pagination requires at least one column")
+	}
+	if len(keyset.After) != 0 && len(keyset.After) != len(keyset.Columns) {
+		return "", nil, fmt.Errorf("keyset has %d columns but %d values", len(keyset.Columns), len(keyset.After))
+	}
+	operator, direction := ">", "ASC"
+	if keyset.Descending {
+		operator, direction = "<", "DESC"
+	}
+
+	queryBuilder := strings.Builder{}
+	queryBuilder.WriteString("SELECT * FROM (")
+	queryBuilder.WriteString(statement)
+	queryBuilder.WriteString(") keyset_source")
+
+	// (c1 > v1) OR (c1 = v1 AND c2 > v2) ... works on engines without row value comparison
+	if len(keyset.After) != 0 {
+		queryBuilder.WriteString(" WHERE ")
+		for i := range keyset.Columns {
+			if i > 0 {
+				queryBuilder.WriteString(" OR ")
+			}
+			queryBuilder.WriteString("(")
+			for j := 0; j <= i; j++ {
+				if j > 0 {
+					queryBuilder.WriteString(" AND ")
+				}
+				comparison := "="
+				if j == i {
+					comparison = operator
+				}
+				agruments = append(agruments, keyset.After[j])
+				queryBuilder.WriteString(fmt.Sprintf("keyset_source.%s %s %s", keyset.Columns[j], comparison, dialect.Placeholder(len(agruments))))
+			}
+			queryBuilder.WriteString(")")
+		}
+	}
+
+	queryBuilder.WriteString(" ORDER BY ")
+	for i, column := range keyset.Columns {
+		if i > 0 {
+			queryBuilder.WriteString(", ")
+		}
+		queryBuilder.WriteString("keyset_source." + column + " " + direction)
+	}
+	query, agruments := dialect.Limit(queryBuilder.String(), limit, agruments)
+	return query, agruments, nil
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeysetQuery(t *testing.T) {
+	after := Keyset{Columns: []string{"name", "id"}, After: []interface{}{"b", 2}}
+	tests := []struct {
+		name      string
+		dialect   Dialect
+		keyset    Keyset
+		want      string
+		wantArgs  []interface{}
+		wantError bool
+	}{
+		{
+			name:    "postgres after",
+			dialect: PostgresDialect,
+			keyset:  after,
+			want: "SELECT * FROM (SELECT id, name FROM t) keyset_source WHERE (keyset_source.name > $2) OR " +
+				"(keyset_source.name = $3 AND keyset_source.id > $4) ORDER BY keyset_source.name ASC, keyset_source.id ASC LIMIT $5",
+			wantArgs: []interface{}{true, "b", "b", 2, uint32(10)},
+		},
+		{
+			name:    "oracle after",
+			dialect: OracleDialect,
+			keyset:  after,
+			want: "SELECT * FROM (SELECT id, name FROM t) keyset_source WHERE (keyset_source.name > :2) OR " +
+				"(keyset_source.name = :3 AND keyset_source.id > :4) ORDER BY keyset_source.name ASC, keyset_source.id ASC FETCH NEXT :5 ROWS ONLY",
+			wantArgs: []interface{}{true, "b", "b", 2, uint32(10)},
+		},
+		{
+			name:    "sqlserver after",
+			dialect: SQLServerDialect,
+			keyset:  after,
+			want: "SELECT * FROM (SELECT id, name FROM t) keyset_source WHERE (keyset_source.name > @p2) OR " +
+				"(keyset_source.name = @p3 AND keyset_source.id > @p4) ORDER BY keyset_source.name ASC, keyset_source.id ASC " +
+				"OFFSET @p5 ROWS FETCH NEXT @p6 ROWS ONLY",
+			wantArgs: []interface{}{true, "b", "b", 2, uint32(0), uint32(10)},
+		},
+		{
+			name:    "mysql after",
+			dialect: MySQLDialect,
+			keyset:  after,
+			want: "SELECT * FROM (SELECT id, name FROM t) keyset_source WHERE (keyset_source.name > ?) OR " +
+				"(keyset_source.name = ? AND keyset_source.id > ?) ORDER BY keyset_source.name ASC, keyset_source.id ASC LIMIT ?",
+			wantArgs: []interface{}{true, "b", "b", 2, uint32(10)},
+		},
+		{
+			name:     "first page descending",
+			dialect:  PostgresDialect,
+			keyset:   Keyset{Columns: []string{"id"}, Descending: true},
+			want:     "SELECT * FROM (SELECT id, name FROM t) keyset_source ORDER BY keyset_source.id DESC LIMIT $2",
+			wantArgs: []interface{}{true, uint32(10)},
+		},
+		{
+			name:      "no columns",
+			dialect:   PostgresDialect,
+			keyset:    Keyset{},
+			wantError: true,
+		},
+		{
+			name:      "values mismatch",
+			dialect:   PostgresDialect,
+			keyset:    Keyset{Columns: []string{"name", "id"}, After: []interface{}{"b"}},
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args, err := KeysetQuery(tt.dialect, "SELECT id, name FROM t", tt.keyset, 10, []interface{}{true})
+			if (err != nil) != tt.wantError {
+				t.Fatalf("KeysetQuery() error = %v, wantError %v", err, tt.wantError)
+			}
+			if got != tt.want {
+				t.Errorf("KeysetQuery() = %q, want %q", got, tt.want)
+			}
+			if !tt.wantError && !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("KeysetQuery() args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	log "go-core/log"
+
+	"github.com/lib/pq"
+)
+
+const (
+	defaultListenerMinReconnect = time.Second
+	defaultListenerMaxReconnect = time.Minute
+	defaultListenerPingInterval = time.Minute
+	defaultListenerBufferSize   = 256
+)
+
+type (
+	// Notification is a message sent by NOTIFY or pg_notify on a Postgres channel
+	Notification struct {
+		Channel string
+		Payload string
+		// PID is the backend process ID of the notifying session
+		PID int
+	}
+
+	// NotifyFunc handles the notifications of a channel, a returned error is logged
+	NotifyFunc func(Notification) error
+
+	// ListenerOption configures a Listener
+	ListenerOption struct {
+		// MinReconnectInterval is the first delay before reconnecting, default 1s, it doubles up to
+		// MaxReconnectInterval, default 1m
+		MinReconnectInterval time.Duration
+		MaxReconnectInterval time.Duration
+		// PingInterval pings the idle connection to detect its loss, default 1m
+		PingInterval time.Duration
+		// BufferSize is the number of notifications waiting for Run, default 256, the notifications
+		// arriving while it is full, e.g. before Run is called or after it returned, are dropped
+		BufferSize int
+		// Reconnected is called once the channels are listened again after a connection loss,
+		// notifications sent while disconnected are lost so caches should be cleared
+		Reconnected func()
+	}
+
+	// Listener receives Postgres notifications on a dedicated connection. The connection is re-established
+	// after a loss, with the current DBConfig.Credentials, and every channel is listened again.
+	Listener struct {
+		cfg    DBConfig
+		option ListenerOption
+		notify chan Notification
+
+		mutex    sync.Mutex
+		conn     *pq.ListenerConn
+		handlers map[string][]NotifyFunc
+
+		stop      chan struct{}
+		closeOnce sync.Once
+	}
+)
+
+// NewListener creates an instance connecting in the background to the postgres database of cfg
+func NewListener(cfg DBConfig, option ListenerOption) *Listener {
+	if option.MinReconnectInterval <= 0 {
+		option.MinReconnectInterval = defaultListenerMinReconnect
+	}
+	if option.MaxReconnectInterval < option.MinReconnectInterval {
+		option.MaxReconnectInterval = defaultListenerMaxReconnect
+	}
+	if option.PingInterval <= 0 {
+		option.PingInterval = defaultListenerPingInterval
+	}
+	if option.BufferSize <= 0 {
+		option.BufferSize = defaultListenerBufferSize
+	}
+	l := &Listener{
+		cfg:      cfg,
+		option:   option,
+		notify:   make(chan Notification, option.BufferSize),
+		handlers: map[string][]NotifyFunc{},
+		stop:     make(chan struct{}),
+	}
+	go l.connectLoop()
+	return l
+}
+
+// connectLoop keeps a connection open and forwards its notifications until the listener is closed
+func (l *Listener) connectLoop() {
+	backoff := l.option.MinReconnectInterval
+	for connected := false; ; {
+		forwarded, err := l.connect()
+		if err != nil {
+			log.Logger.Warnw("Database listener failed to connect", "db.instance", l.cfg.Database, "error", err)
+			select {
+			case <-l.stop:
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > l.option.MaxReconnectInterval {
+				backoff = l.option.MaxReconnectInterval
+			}
+			continue
+		}
+		backoff = l.option.MinReconnectInterval
+		if connected {
+			log.Logger.Infow("Database listener reconnected", "db.instance", l.cfg.Database)
+			if l.option.Reconnected != nil {
+				l.option.Reconnected()
+			}
+		}
+		connected = true
+
+		<-forwarded
+		l.mutex.Lock()
+		err = l.conn.Err()
+		l.conn = nil
+		l.mutex.Unlock()
+		select {
+		case <-l.stop:
+			return
+		default:
+		}
+		log.Logger.Warnw("Database listener disconnected", "db.instance", l.cfg.Database, "error", err)
+	}
+}
+
+// connect opens a connection with the current credentials and listens to every channel with a handler,
+// the returned channel is closed once the connection is lost or closed
+func (l *Listener) connect() (<-chan struct{}, error) {
+	cfg := l.cfg
+	if cfg.Credentials != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), l.option.MaxReconnectInterval)
+		credentials, err := cfg.Credentials.Credentials(ctx)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		if credentials.Username != "" {
+			cfg.Username = credentials.Username
+		}
+		cfg.Password = credentials.Password
+	}
+	dsn, err := postgresDSN(cfg)
+	if err != nil {
+		return nil, err
+	}
+	notifications := make(chan *pq.Notification, 32)
+	conn, err := pq.NewListenerConn(dsn, notifications)
+	if err != nil {
+		return nil, err
+	}
+	// the connection stops reading replies while a notification cannot be delivered
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		l.forward(notifications)
+	}()
+
+	// LISTEN waits for its reply so it runs without the mutex, the channels added meanwhile are listened
+	// by the next pass
+	listened := map[string]bool{}
+	for {
+		l.mutex.Lock()
+		var channels []string
+		for channel := range l.handlers {
+			if !listened[channel] {
+				channels = append(channels, channel)
+			}
+		}
+		if len(channels) == 0 {
+			select {
+			case <-l.stop:
+				l.mutex.Unlock()
+				_ = conn.Close()
+				return nil, errors.New("db: listener closed")
+			default:
+			}
+			l.conn = conn
+			l.mutex.Unlock()
+			return forwarded, nil
+		}
+		l.mutex.Unlock()
+
+		for _, channel := range channels {
+			if _, err := conn.Listen(channel); err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			listened[channel] = true
+		}
+	}
+}
+
+// forward passes the notifications of a connection to Run until it is lost, they are dropped when
+// the buffer is full so the connection keeps reading
+func (l *Listener) forward(notifications <-chan *pq.Notification) {
+	for notification := range notifications {
+		select {
+		case l.notify <- Notification{Channel: notification.Channel, Payload: notification.Extra, PID: notification.BePid}:
+		default:
+			log.Logger.Warnw("Dropped database notification, the listener buffer is full", "channel", notification.Channel,
+				"pid", notification.BePid)
+		}
+	}
+}
+
+// Listen adds fn to the handlers of channel, the first handler of a channel issues the LISTEN. While the
+// listener is disconnected the channel is listened once the connection is re-established. Channel names
+// are case sensitive.
+func (l *Listener) Listen(channel string, fn NotifyFunc) error {
+	l.mutex.Lock()
+	first, conn := len(l.handlers[channel]) == 0, l.conn
+	l.handlers[channel] = append(l.handlers[channel], fn)
+	l.mutex.Unlock()
+	if !first || conn == nil {
+		return nil
+	}
+	// without a response the connection is being lost, the reconnection listens to the channel
+	if gotResponse, err := conn.Listen(channel); gotResponse && err != nil {
+		l.mutex.Lock()
+		l.removeFirstHandler(channel)
+		l.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// removeFirstHandler removes the handler which issued the failed LISTEN of channel
+func (l *Listener) removeFirstHandler(channel string) {
+	if handlers := l.handlers[channel]; len(handlers) > 1 {
+		l.handlers[channel] = handlers[1:]
+	} else {
+		delete(l.handlers, channel)
+	}
+}
+
+// Unlisten removes the handlers of channel
+func (l *Listener) Unlisten(channel string) error {
+	l.mutex.Lock()
+	_, ok := l.handlers[channel]
+	delete(l.handlers, channel)
+	conn := l.conn
+	l.mutex.Unlock()
+	if !ok || conn == nil {
+		return nil
+	}
+	if gotResponse, err := conn.Unlisten(channel); gotResponse && err != nil {
+		return err
+	}
+	return nil
+}
+
+// Run dispatches the notifications to the handlers until ctx is done. The handlers run in order on the calling
+// goroutine, a slow handler delays the following notifications which are dropped once BufferSize is exceeded.
+func (l *Listener) Run(ctx context.Context) error {
+	ticker := time.NewTicker(l.option.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case notification := <-l.notify:
+			l.dispatch(notification)
+		case <-ticker.C:
+			l.mutex.Lock()
+			conn := l.conn
+			l.mutex.Unlock()
+			if conn != nil {
+				go func() {
+					// a failed ping closes the connection, which is then re-established
+					_ = conn.Ping()
+				}()
+			}
+		}
+	}
+}
+
+func (l *Listener) dispatch(notification Notification) {
+	l.mutex.Lock()
+	handlers := l.handlers[notification.Channel]
+	l.mutex.Unlock()
+	for _, fn := range handlers {
+		if err := fn(notification); err != nil {
+			log.Logger.Warnw("Failed to handle database notification", "channel", notification.Channel,
+				"pid", notification.PID, "error", err)
+		}
+	}
+}
+
+// Close closes the connection and stops reconnecting
+func (l *Listener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.stop)
+	})
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.conn != nil {
+		return l.conn.Close()
+	}
+	return nil
+}
+
+// DecodeNotification decodes the JSON payload of notification, e.g. sent by
+//
+//	PERFORM pg_notify('users', json_build_object('op', TG_OP, 'id', NEW.id)::text);
+func DecodeNotification[T any](notification Notification) (T, error) {
+	var payload T
+	err := json.Unmarshal([]byte(notification.Payload), &payload)
+	return payload, err
+}
+
+// NotifyJSON returns a handler decoding the JSON payload into T before calling fn
+func NotifyJSON[T any](fn func(channel string, payload T) error) NotifyFunc {
+	return func(notification Notification) error {
+		payload, err := DecodeNotification[T](notification)
+		if err != nil {
+			return err
+		}
+		return fn(notification.Channel, payload)
+	}
+}
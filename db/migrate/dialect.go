@@ -0,0 +1,66 @@
+package migrate
+
+import (
+	"fmt"
+)
+
+// engine holds the statements the migrator needs per database engine
+type engine struct {
+	createTable string
+	lock        string
+	unlock      string
+}
+
+// engines is keyed by db.Dialect name, %[1]s is the schema table, the lock statements take the lock name as first argument.
+// An empty lock means the engine serializes writers by itself.
+var engines = map[string]engine{
+	"postgres": {
+		createTable: `CREATE TABLE IF NOT EXISTS %[1]s (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL)`,
+		lock:        `SELECT pg_advisory_lock(hashtext($1))`,
+		unlock:      `SELECT pg_advisory_unlock(hashtext($1))`,
+	},
+	"oracle": {
+		createTable: `BEGIN
+	EXECUTE IMMEDIATE 'CREATE TABLE %[1]s (version NUMBER(19) PRIMARY KEY, name VARCHAR2(255) NOT NULL, applied_at TIMESTAMP NOT NULL)';
+EXCEPTION WHEN OTHERS THEN
+	IF SQLCODE != -955 THEN RAISE; END IF;
+END;`,
+		lock: `DECLARE
+	l_handle VARCHAR2(128);
+	l_status NUMBER;
+BEGIN
+	DBMS_LOCK.ALLOCATE_UNIQUE(:1, l_handle);
+	l_status := DBMS_LOCK.REQUEST(l_handle, DBMS_LOCK.X_MODE, DBMS_LOCK.MAXWAIT, FALSE);
+	IF l_status NOT IN (0, 4) THEN RAISE_APPLICATION_ERROR(-20000, 'migration lock failed: ' || l_status); END IF;
+END;`,
+		unlock: `DECLARE
+	l_handle VARCHAR2(128);
+	l_status NUMBER;
+BEGIN
+	DBMS_LOCK.ALLOCATE_UNIQUE(:1, l_handle);
+	l_status := DBMS_LOCK.RELEASE(l_handle);
+END;`,
+	},
+	"sqlserver": {
+		createTable: `IF OBJECT_ID(N'%[1]s', N'U') IS NULL CREATE TABLE %[1]s (version BIGINT PRIMARY KEY, name NVARCHAR(255) NOT NULL, applied_at DATETIME2 NOT NULL)`,
+		lock:        `EXEC sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = -1`,
+		unlock:      `EXEC sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'`,
+	},
+	// DDL commits implicitly on mysql, a failing migration may leave its earlier statements applied
+	"mysql": {
+		createTable: `CREATE TABLE IF NOT EXISTS %[1]s (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at DATETIME(6) NOT NULL)`,
+		lock:        `SELECT GET_LOCK(?, -1)`,
+		unlock:      `SELECT RELEASE_LOCK(?)`,
+	},
+	"sqlite": {
+		createTable: `CREATE TABLE IF NOT EXISTS %[1]s (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMP NOT NULL)`,
+	},
+}
+
+func engineOf(name string) (engine, error) {
+	engine, ok := engines[name]
+	if !ok {
+		return engine, fmt.Errorf("migrations are not supported on %s", name)
+	}
+	return engine, nil
+}
@@ -0,0 +1,252 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go-core/db"
+)
+
+const (
+	defaultTable    = "schema_migrations"
+	defaultLockName = "go-core-migrate"
+)
+
+// ErrNoDownMigration is returned when rolling back a version without down file
+var ErrNoDownMigration = errors.New("no down migration")
+
+type (
+	// Option represents migrator option
+	Option struct {
+		// Table records the applied versions, default schema_migrations
+		Table string
+		// LockName identifies the advisory lock taken while migrating, default go-core-migrate
+		LockName string
+		// DryRun prints the plan and the statements without executing them
+		DryRun bool
+		// Output receives progress and the dry-run plan, default discards
+		Output io.Writer
+	}
+
+	// Status reports whether a migration is applied
+	Status struct {
+		Migration
+		Applied   bool
+		AppliedAt time.Time
+	}
+
+	// Migrator applies migrations against a DBHelper
+	Migrator struct {
+		helper     db.DBHelper
+		migrations []Migration
+		option     Option
+		engine     engine
+	}
+
+	step struct {
+		migration Migration
+		up        bool
+	}
+)
+
+// New creates an instance, migrations are usually loaded with Load or LoadDir
+func New(helper db.DBHelper, migrations []Migration, option Option) (*Migrator, error) {
+	engine, err := engineOf(helper.Dialect().Name())
+	if err != nil {
+		return nil, err
+	}
+	if option.Table == "" {
+		option.Table = defaultTable
+	}
+	if option.LockName == "" {
+		option.LockName = defaultLockName
+	}
+	if option.Output == nil {
+		option.Output = io.Discard
+	}
+	return &Migrator{
+		helper:     helper,
+		migrations: migrations,
+		option:     option,
+		engine:     engine,
+	}, nil
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up(ctx context.Context) error {
+	if len(m.migrations) == 0 {
+		return nil
+	}
+	return m.To(ctx, m.migrations[len(m.migrations)-1].Version)
+}
+
+// Down rolls back the last steps applied migrations
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.run(ctx, func(applied map[int64]time.Time) ([]step, error) {
+		var plan []step
+		for i := len(m.migrations) - 1; i >= 0 && len(plan) < steps; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				plan = append(plan, step{migration: m.migrations[i]})
+			}
+		}
+		return plan, nil
+	})
+}
+
+// To migrates up or down so that exactly the migrations up to version are applied
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	return m.run(ctx, func(applied map[int64]time.Time) ([]step, error) {
+		var plan []step
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok && m.migrations[i].Version > version {
+				plan = append(plan, step{migration: m.migrations[i]})
+			}
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+				plan = append(plan, step{migration: migration, up: true})
+			}
+		}
+		return plan, nil
+	})
+}
+
+// Status lists every known migration with its state
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.helper.Open().Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		result[i] = Status{Migration: migration, Applied: ok, AppliedAt: appliedAt}
+	}
+	return result, nil
+}
+
+func (m *Migrator) run(ctx context.Context, planner func(applied map[int64]time.Time) ([]step, error)) (err error) {
+	// the lock is bound to the session, so everything runs on a single connection
+	conn, err := m.helper.Open().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if !m.option.DryRun {
+		// the lock is taken first so concurrent first runs do not race creating the schema table
+		if m.engine.lock != "" {
+			if _, err = conn.ExecContext(ctx, m.engine.lock, m.option.LockName); err != nil {
+				return fmt.Errorf("lock: %w", err)
+			}
+			defer func() {
+				if _, errUnlock := conn.ExecContext(context.Background(), m.engine.unlock, m.option.LockName); errUnlock != nil && err == nil {
+					err = fmt.Errorf("unlock: %w", errUnlock)
+				}
+			}()
+		}
+		if _, err = conn.ExecContext(ctx, fmt.Sprintf(m.engine.createTable, m.option.Table)); err != nil {
+			return fmt.Errorf("create %s: %w", m.option.Table, err)
+		}
+	}
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		if !m.option.DryRun {
+			return err
+		}
+		// the schema table does not exist before the first real run
+		applied = map[int64]time.Time{}
+	}
+	plan, err := planner(applied)
+	if err != nil {
+		return err
+	}
+	if len(plan) == 0 {
+		fmt.Fprintln(m.option.Output, "no change")
+		return nil
+	}
+	for _, item := range plan {
+		if err = m.apply(ctx, conn, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT version, applied_at FROM %s", m.option.Table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, item step) (err error) {
+	var (
+		direction = "down"
+		body      = item.migration.Down
+		dialect   = m.helper.Dialect()
+		record    = fmt.Sprintf("DELETE FROM %s WHERE version = %s", m.option.Table, dialect.Placeholder(1))
+		arguments = []interface{}{item.migration.Version}
+	)
+	if item.up {
+		direction, body = "up", item.migration.Up
+		record = fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (%s, %s, %s)",
+			m.option.Table, dialect.Placeholder(1), dialect.Placeholder(2), dialect.Placeholder(3))
+		arguments = append(arguments, item.migration.Name, time.Now().UTC())
+	}
+	if body == "" {
+		return fmt.Errorf("%d_%s: %w", item.migration.Version, item.migration.Name, ErrNoDownMigration)
+	}
+
+	fmt.Fprintf(m.option.Output, "%s %d_%s\n", direction, item.migration.Version, item.migration.Name)
+	if m.option.DryRun {
+		for _, statement := range Statements(body) {
+			fmt.Fprintf(m.option.Output, "%s\n", statement)
+		}
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	for _, statement := range Statements(body) {
+		if _, err = tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%s %d_%s: %w", direction, item.migration.Version, item.migration.Name, err)
+		}
+	}
+	if _, err = tx.ExecContext(ctx, record, arguments...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,125 @@
+package migrate_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"go-core/db"
+	"go-core/db/dbtest"
+	"go-core/db/migrate"
+)
+
+var migrations = fstest.MapFS{
+	"1_users.up.sql":    {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)\nGO\nCREATE INDEX users_name ON users (name)")},
+	"1_users.down.sql":  {Data: []byte("DROP TABLE users")},
+	"2_emails.up.sql":   {Data: []byte("ALTER TABLE users ADD COLUMN email TEXT")},
+	"2_emails.down.sql": {Data: []byte("ALTER TABLE users DROP COLUMN email")},
+	"3_seed.up.sql":     {Data: []byte("INSERT INTO users (name) VALUES ('admin')")},
+	"README.md":         {Data: []byte("not a migration")},
+}
+
+func TestStatements(t *testing.T) {
+	got := migrate.Statements("CREATE TABLE a (id INT);\n go \nCREATE TABLE b (id INT);\nGO\n\nGO\n")
+	want := []string{"CREATE TABLE a (id INT);", "CREATE TABLE b (id INT);"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Statements() = %q, want %q", got, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	loaded, err := migrate.Load(migrations)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 3 || loaded[0].Version != 1 || loaded[0].Name != "users" || loaded[2].Down != "" {
+		t.Errorf("Load() = %+v", loaded)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	helper, _ := dbtest.NewSQLite(t)
+	loaded, err := migrate.Load(migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := &strings.Builder{}
+	migrator, err := migrate.New(helper, loaded, migrate.Option{Output: output})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	assertApplied(t, migrator, true, true, true)
+	if count, err := db.Get[int64](ctx, helper, "SELECT COUNT(*) FROM users WHERE email IS NULL"); err != nil || count != 1 {
+		t.Errorf("users = %d, %v, want 1", count, err)
+	}
+
+	// the seed has no down migration
+	if err := migrator.Down(ctx, 1); err == nil || !strings.Contains(err.Error(), migrate.ErrNoDownMigration.Error()) {
+		t.Errorf("Down() error = %v, want %v", err, migrate.ErrNoDownMigration)
+	}
+	if err := migrator.To(ctx, 1); err == nil {
+		t.Error("To(1) error = nil, want the missing down migration of 3_seed")
+	}
+
+	helper2, _ := dbtest.NewSQLite(t)
+	migrator2, err := migrate.New(helper2, loaded[:2], migrate.Option{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator2.Up(ctx); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if err := migrator2.Down(ctx, 1); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	assertApplied(t, migrator2, true, false)
+	if err := migrator2.To(ctx, 0); err != nil {
+		t.Fatalf("To(0) error = %v", err)
+	}
+	assertApplied(t, migrator2, false, false)
+	if _, err := helper2.ExecContext(ctx, "SELECT * FROM users"); err == nil {
+		t.Error("users exists after migrating to 0")
+	}
+}
+
+func TestMigratorDryRun(t *testing.T) {
+	helper, recorder := dbtest.NewSQLite(t)
+	loaded, err := migrate.Load(migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := &strings.Builder{}
+	migrator, err := migrate.New(helper, loaded, migrate.Option{DryRun: true, Output: output})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if !strings.Contains(output.String(), "up 1_users\nCREATE TABLE users") {
+		t.Errorf("plan = %q", output.String())
+	}
+	recorder.AssertNotExecuted(t, "CREATE TABLE users")
+}
+
+func assertApplied(t *testing.T, migrator *migrate.Migrator, want ...bool) {
+	t.Helper()
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	applied := make([]bool, len(statuses))
+	for i, status := range statuses {
+		applied[i] = status.Applied
+	}
+	if !reflect.DeepEqual(applied, want) {
+		t.Errorf("applied = %v, want %v", applied, want)
+	}
+}
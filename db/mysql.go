@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+
+	log "go-core/log"
+
+	"go.uber.org/zap"
+)
+
+type mysqlDBHelper struct {
+	*baseDBHelper
+}
+
+// NewMySQLDBHelper creates an instance, it also connects to MariaDB
+func NewMySQLDBHelper(host string, port int, username, password, database string, opts ...DBOption) DBHelper {
+	return NewMySQLDBHelperWithConfig(DBConfig{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		Database: database,
+	}, opts...)
+}
+
+// NewMySQLDBHelperWithConfig creates an instance from config
+func NewMySQLDBHelperWithConfig(cfg DBConfig, opts ...DBOption) DBHelper {
+	helper, err := OpenMySQLDBHelper(context.Background(), cfg, opts...)
+	if err != nil {
+		log.Logger.Panic("Failed to init mysql", zap.Error(err))
+	}
+	return helper
+}
+
+// OpenMySQLDBHelper creates an instance from config, the connection is retried according to the
+// connect_retry option within ctx, with lazy_connect it is established in the background
+func OpenMySQLDBHelper(ctx context.Context, cfg DBConfig, opts ...DBOption) (DBHelper, error) {
+	base, err := openBaseDBHelper(ctx, "mysqlDBHelper", "mysql", mysqlDSN, MySQLDialect, cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &mysqlDBHelper{baseDBHelper: base}, nil
+}
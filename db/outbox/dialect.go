@@ -0,0 +1,136 @@
+package outbox
+
+import (
+	"fmt"
+
+	"go-core/db"
+)
+
+// statements holds the outbox statements rendered for a dialect
+type statements struct {
+	insert string
+	// poll locks the next pending events, it takes the current time and the batch size unless unbounded
+	poll string
+	// unbounded polls lock the rows as they are fetched, the relay stops reading after a batch
+	unbounded bool
+	sent      string
+	failed    string
+}
+
+// schemas is keyed by db.Dialect name, %[1]s is the table
+var schemas = map[string]string{
+	"postgres": `CREATE TABLE IF NOT EXISTS %[1]s (
+	id BIGSERIAL PRIMARY KEY,
+	topic VARCHAR(255) NOT NULL,
+	event_key VARCHAR(255) NOT NULL,
+	payload TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	available_at TIMESTAMP NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT,
+	sent_at TIMESTAMP
+);
+GO
+CREATE INDEX IF NOT EXISTS %[1]s_pending ON %[1]s (available_at) WHERE sent_at IS NULL;`,
+	"mysql": `CREATE TABLE IF NOT EXISTS %[1]s (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	topic VARCHAR(255) NOT NULL,
+	event_key VARCHAR(255) NOT NULL,
+	payload LONGTEXT NOT NULL,
+	created_at DATETIME(6) NOT NULL,
+	available_at DATETIME(6) NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT,
+	sent_at DATETIME(6),
+	INDEX %[1]s_pending (sent_at, available_at)
+);`,
+	"oracle": `CREATE TABLE %[1]s (
+	id NUMBER(19) GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	topic VARCHAR2(255) NOT NULL,
+	event_key VARCHAR2(255),
+	payload CLOB NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	available_at TIMESTAMP NOT NULL,
+	attempts NUMBER(10) DEFAULT 0 NOT NULL,
+	last_error VARCHAR2(4000),
+	sent_at TIMESTAMP
+)
+GO
+CREATE INDEX %[1]s_pending ON %[1]s (sent_at, available_at)`,
+	"sqlserver": `IF OBJECT_ID(N'%[1]s', N'U') IS NULL
+CREATE TABLE %[1]s (
+	id BIGINT IDENTITY PRIMARY KEY,
+	topic NVARCHAR(255) NOT NULL,
+	event_key NVARCHAR(255) NOT NULL,
+	payload NVARCHAR(MAX) NOT NULL,
+	created_at DATETIME2 NOT NULL,
+	available_at DATETIME2 NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	last_error NVARCHAR(MAX),
+	sent_at DATETIME2,
+	INDEX %[1]s_pending (sent_at, available_at)
+);`,
+	"sqlite": `CREATE TABLE IF NOT EXISTS %[1]s (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	topic TEXT NOT NULL,
+	event_key TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	available_at TIMESTAMP NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT,
+	sent_at TIMESTAMP
+);
+GO
+CREATE INDEX IF NOT EXISTS %[1]s_pending ON %[1]s (sent_at, available_at);`,
+}
+
+// Schema returns the DDL creating table, to be added to the service migrations.
+// Its statements are separated by GO lines, as split by migrate.Statements.
+func Schema(dialect db.Dialect, table string) (string, error) {
+	schema, ok := schemas[dialect.Name()]
+	if !ok {
+		return "", fmt.Errorf("outbox is not supported on %s", dialect.Name())
+	}
+	if table == "" {
+		table = defaultTable
+	}
+	return fmt.Sprintf(schema, table), nil
+}
+
+// statementsOf renders the statements, events failing maxAttempts times are no longer polled
+func statementsOf(dialect db.Dialect, table string, maxAttempts int) (statements, error) {
+	p := dialect.Placeholder
+	columns := "id, topic, event_key, payload, created_at, attempts"
+	pending := fmt.Sprintf("sent_at IS NULL AND available_at <= %s", p(1))
+	if maxAttempts > 0 {
+		pending += fmt.Sprintf(" AND attempts < %d", maxAttempts)
+	}
+
+	var poll string
+	switch dialect.Name() {
+	case "postgres", "mysql":
+		poll = fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY id LIMIT %s FOR UPDATE SKIP LOCKED", columns, table, pending, p(2))
+	case "oracle":
+		// ROWNUM is applied before SKIP LOCKED, so a second relay would get the rows locked by the first
+		// and return nothing, the rows are instead locked as they are fetched
+		poll = fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY id FOR UPDATE SKIP LOCKED", columns, table, pending)
+	case "sqlserver":
+		// the batch size is the second argument, TOP comes first so it binds @p2
+		poll = fmt.Sprintf("SELECT TOP (%s) %s FROM %s WITH (UPDLOCK, READPAST, ROWLOCK) WHERE %s ORDER BY id", p(2), columns, table, pending)
+	case "sqlite":
+		// sqlite has no row locks, the relay transactions are serialized by the database lock
+		poll = fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY id LIMIT %s", columns, table, pending, p(2))
+	default:
+		return statements{}, fmt.Errorf("outbox is not supported on %s", dialect.Name())
+	}
+
+	return statements{
+		insert: fmt.Sprintf("INSERT INTO %s (topic, event_key, payload, created_at, available_at, attempts) VALUES (%s, %s, %s, %s, %s, 0)",
+			table, p(1), p(2), p(3), p(4), p(5)),
+		poll:      poll,
+		unbounded: dialect.Name() == "oracle",
+		sent:      fmt.Sprintf("UPDATE %s SET sent_at = %s, attempts = attempts + 1 WHERE id = %s", table, p(1), p(2)),
+		failed:    fmt.Sprintf("UPDATE %s SET available_at = %s, attempts = attempts + 1, last_error = %s WHERE id = %s", table, p(1), p(2), p(3)),
+	}, nil
+}
@@ -0,0 +1,136 @@
+// Package outbox implements the transactional outbox: events are inserted in the transaction of the
+// business change and a relay publishes them afterwards, so an event is published if and only if
+// the change is committed (at least once).
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-core/db"
+)
+
+const (
+	defaultTable        = "outbox"
+	defaultBatchSize    = 100
+	defaultPollInterval = time.Second
+	defaultRetryBackoff = time.Second
+	defaultMaxBackoff   = 5 * time.Minute
+)
+
+type (
+	// Option represents outbox option
+	Option struct {
+		// Table stores the events, default outbox, see Schema
+		Table string
+		// BatchSize is the number of events relayed per transaction, default 100
+		BatchSize int
+		// PollInterval is the delay between polls when the outbox is drained, default 1s
+		PollInterval time.Duration
+		// RetryBackoff is the first delay before publishing a failed event again, it doubles up to MaxBackoff
+		// (default 1s and 5m)
+		RetryBackoff time.Duration
+		MaxBackoff   time.Duration
+		// MaxAttempts stops retrying an event after that many failures, zero retries forever.
+		// Abandoned events stay in the table with their last_error.
+		MaxAttempts int
+	}
+
+	// Message is an event to enqueue
+	Message struct {
+		// Topic is the pub/sub channel or the stream receiving the event
+		Topic string
+		// Key identifies the aggregate, optional
+		Key string
+		// Payload is stored as is when it is a string, []byte or json.RawMessage and as JSON otherwise
+		Payload interface{}
+	}
+
+	// Event is an enqueued message read by the relay
+	Event struct {
+		ID        int64
+		Topic     string
+		Key       string
+		Payload   []byte
+		CreatedAt time.Time
+		Attempts  int
+	}
+
+	// Outbox enqueues and relays events of a DBHelper
+	Outbox struct {
+		helper     db.DBHelper
+		option     Option
+		statements statements
+	}
+)
+
+// New creates an instance, the table must exist (see Schema)
+func New(helper db.DBHelper, option Option) (*Outbox, error) {
+	if option.Table == "" {
+		option.Table = defaultTable
+	}
+	if option.BatchSize <= 0 {
+		option.BatchSize = defaultBatchSize
+	}
+	if option.PollInterval <= 0 {
+		option.PollInterval = defaultPollInterval
+	}
+	if option.RetryBackoff <= 0 {
+		option.RetryBackoff = defaultRetryBackoff
+	}
+	if option.MaxBackoff <= 0 {
+		option.MaxBackoff = defaultMaxBackoff
+	}
+	statements, err := statementsOf(helper.Dialect(), option.Table, option.MaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	return &Outbox{helper: helper, option: option, statements: statements}, nil
+}
+
+// Enqueue inserts messages within tx, typically started by DBHelper.Begin or WithTx,
+// they are relayed once tx is committed
+func (o *Outbox) Enqueue(ctx context.Context, tx *sql.Tx, messages ...Message) error {
+	now := time.Now().UTC()
+	for _, message := range messages {
+		payload, err := encodePayload(message.Payload)
+		if err != nil {
+			return fmt.Errorf("outbox payload of %s: %w", message.Topic, err)
+		}
+		if _, err := tx.ExecContext(ctx, o.statements.insert, message.Topic, message.Key, payload, now, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodePayload(payload interface{}) (string, error) {
+	switch value := payload.(type) {
+	case string:
+		return value, nil
+	case []byte:
+		return string(value), nil
+	case json.RawMessage:
+		return string(value), nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// backoff returns the delay before the next attempt of an event that failed attempts times
+func (o *Outbox) backoff(attempts int) time.Duration {
+	backoff := o.option.RetryBackoff
+	for i := 1; i < attempts && backoff < o.option.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > o.option.MaxBackoff {
+		backoff = o.option.MaxBackoff
+	}
+	return backoff
+}
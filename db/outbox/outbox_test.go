@@ -0,0 +1,123 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-core/db"
+	"go-core/db/dbtest"
+	"go-core/db/migrate"
+	"go-core/db/outbox"
+	log "go-core/log"
+)
+
+func TestSchema(t *testing.T) {
+	dialects := []db.Dialect{db.PostgresDialect, db.OracleDialect, db.SQLServerDialect, db.MySQLDialect, db.SQLiteDialect}
+	for _, dialect := range dialects {
+		t.Run(dialect.Name(), func(t *testing.T) {
+			schema, err := outbox.Schema(dialect, "events")
+			if err != nil {
+				t.Fatalf("Schema() error = %v", err)
+			}
+			for _, statement := range migrate.Statements(schema) {
+				if !strings.Contains(statement, "events") || strings.Count(statement, ";") > 1 {
+					t.Errorf("statement %q is not a single statement on events", statement)
+				}
+				// the oci8 driver rejects a trailing semicolon
+				if dialect == db.OracleDialect && strings.HasSuffix(statement, ";") {
+					t.Errorf("oracle statement %q ends with a semicolon", statement)
+				}
+			}
+		})
+	}
+}
+
+func newOutbox(t *testing.T, option outbox.Option) (db.DBHelper, *outbox.Outbox) {
+	t.Helper()
+	log.InitZap("test", "prod", nil)
+	helper, _ := dbtest.NewSQLite(t)
+	schema, err := outbox.Schema(helper.Dialect(), option.Table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range migrate.Statements(schema) {
+		if _, err := helper.ExecContext(context.Background(), statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	box, err := outbox.New(helper, option)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return helper, box
+}
+
+func enqueue(t *testing.T, helper db.DBHelper, box *outbox.Outbox, messages ...outbox.Message) {
+	t.Helper()
+	err := helper.WithTxContext(context.Background(), nil, func(ctx context.Context, tx *sql.Tx) error {
+		return box.Enqueue(ctx, tx, messages...)
+	})
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+}
+
+func TestRelayOnce(t *testing.T) {
+	ctx := context.Background()
+	helper, box := newOutbox(t, outbox.Option{BatchSize: 2})
+	enqueue(t, helper, box,
+		outbox.Message{Topic: "users", Key: "1", Payload: map[string]int{"id": 1}},
+		outbox.Message{Topic: "users", Key: "2", Payload: "raw"},
+		outbox.Message{Topic: "orders", Payload: []byte("bytes")})
+
+	var published []outbox.Event
+	sink := outbox.SinkFunc(func(ctx context.Context, event outbox.Event) error {
+		published = append(published, event)
+		return nil
+	})
+	if relayed, err := box.RelayOnce(ctx, sink); err != nil || relayed != 2 {
+		t.Fatalf("RelayOnce() = %d, %v, want 2 events", relayed, err)
+	}
+	if relayed, err := box.RelayOnce(ctx, sink); err != nil || relayed != 1 {
+		t.Fatalf("RelayOnce() = %d, %v, want 1 event", relayed, err)
+	}
+	if relayed, err := box.RelayOnce(ctx, sink); err != nil || relayed != 0 {
+		t.Fatalf("RelayOnce() = %d, %v, want a drained outbox", relayed, err)
+	}
+
+	want := []string{`users 1 {"id":1}`, "users 2 raw", "orders  bytes"}
+	if len(published) != len(want) {
+		t.Fatalf("published %d events, want %d", len(published), len(want))
+	}
+	for i, event := range published {
+		if got := event.Topic + " " + event.Key + " " + string(event.Payload); got != want[i] {
+			t.Errorf("event %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestRelayOnceFailure(t *testing.T) {
+	ctx := context.Background()
+	helper, box := newOutbox(t, outbox.Option{RetryBackoff: time.Hour, MaxAttempts: 1})
+	enqueue(t, helper, box, outbox.Message{Topic: "users", Payload: "a"})
+
+	failing := outbox.SinkFunc(func(context.Context, outbox.Event) error {
+		return errors.New("unavailable")
+	})
+	if relayed, err := box.RelayOnce(ctx, failing); err != nil || relayed != 1 {
+		t.Fatalf("RelayOnce() = %d, %v, want 1 event", relayed, err)
+	}
+	// the event is neither due nor below MaxAttempts anymore
+	if relayed, err := box.RelayOnce(ctx, failing); err != nil || relayed != 0 {
+		t.Fatalf("RelayOnce() = %d, %v, want no event", relayed, err)
+	}
+
+	lastError, err := db.Get[string](ctx, helper, "SELECT last_error FROM outbox WHERE attempts = 1 AND sent_at IS NULL")
+	if err != nil || lastError != "unavailable" {
+		t.Errorf("last_error = %q, %v, want unavailable", lastError, err)
+	}
+}
@@ -0,0 +1,82 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	log "go-core/log"
+)
+
+// Run relays the events to sink until ctx is done, polling every PollInterval once the outbox is drained.
+// Several replicas may run it concurrently, locked events are skipped by the others.
+func (o *Outbox) Run(ctx context.Context, sink Sink) error {
+	for {
+		relayed, err := o.RelayOnce(ctx, sink)
+		if err != nil && ctx.Err() == nil {
+			log.Logger.Errorw("Failed to relay outbox events", "table", o.option.Table, "error", err)
+		}
+		if err == nil && relayed == o.option.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(o.option.PollInterval):
+		}
+	}
+}
+
+// RelayOnce locks a batch of pending events, publishes them to sink and marks them sent, or schedules
+// a retry with backoff when publishing fails. It returns the number of events read.
+func (o *Outbox) RelayOnce(ctx context.Context, sink Sink) (relayed int, err error) {
+	err = o.helper.WithTxContext(ctx, nil, func(ctx context.Context, tx *sql.Tx) error {
+		events, err := o.poll(ctx, tx)
+		if err != nil {
+			return err
+		}
+		relayed = len(events)
+		for _, event := range events {
+			now := time.Now().UTC()
+			if errPublish := sink.Publish(ctx, event); errPublish != nil {
+				log.Logger.Warnw("Failed to publish outbox event", "id", event.ID, "topic", event.Topic,
+					"attempts", event.Attempts+1, "error", errPublish)
+				_, err = tx.ExecContext(ctx, o.statements.failed, now.Add(o.backoff(event.Attempts+1)), errPublish.Error(), event.ID)
+			} else {
+				_, err = tx.ExecContext(ctx, o.statements.sent, now, event.ID)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return relayed, err
+}
+
+func (o *Outbox) poll(ctx context.Context, tx *sql.Tx) ([]Event, error) {
+	agruments := []interface{}{time.Now().UTC()}
+	if !o.statements.unbounded {
+		agruments = append(agruments, o.option.BatchSize)
+	}
+	rows, err := tx.QueryContext(ctx, o.statements.poll, agruments...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for len(events) < o.option.BatchSize && rows.Next() {
+		var (
+			event   Event
+			key     sql.NullString
+			payload string
+		)
+		if err := rows.Scan(&event.ID, &event.Topic, &key, &payload, &event.CreatedAt, &event.Attempts); err != nil {
+			return nil, err
+		}
+		event.Key, event.Payload = key.String, []byte(payload)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
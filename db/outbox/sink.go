@@ -0,0 +1,45 @@
+package outbox
+
+import (
+	"context"
+	"strconv"
+
+	"go-core/cache"
+)
+
+type (
+	// Sink publishes relayed events, returning an error schedules a retry
+	Sink interface {
+		Publish(ctx context.Context, event Event) error
+	}
+
+	// SinkFunc adapts a function to Sink
+	SinkFunc func(ctx context.Context, event Event) error
+)
+
+// Publish calls f
+func (f SinkFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// PubSubSink publishes the payload on the channel named by the event topic. PublishMessage fails
+// when no client is subscribed, so events are retried until a subscriber listens.
+func PubSubSink(helper cache.CacheHelper) Sink {
+	return SinkFunc(func(ctx context.Context, event Event) error {
+		return helper.PublishMessage(ctx, event.Topic, string(event.Payload))
+	})
+}
+
+// StreamSink appends the event to the stream named by the event topic with the fields id, key and payload,
+// the stream is trimmed to about maxLen entries (zero keeps every entry). Consumers should deduplicate on id
+// since an event is published again when marking it sent fails.
+func StreamSink(helper cache.CacheHelper, maxLen int64) Sink {
+	return SinkFunc(func(ctx context.Context, event Event) error {
+		_, err := helper.StreamAdd(ctx, event.Topic, maxLen, map[string]interface{}{
+			"id":      strconv.FormatInt(event.ID, 10),
+			"key":     event.Key,
+			"payload": string(event.Payload),
+		})
+		return err
+	})
+}
@@ -0,0 +1,263 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultPageSize = 50
+
+var (
+	// ErrInvalidPageToken is returned when a page token was not issued by SelectPage or SelectKeysetPage
+	ErrInvalidPageToken = errors.New("db: invalid page token")
+
+	// MaxPageSize caps PageRequest.PageSize, which usually comes from the client
+	MaxPageSize uint32 = 1000
+)
+
+type (
+	// PageRequest mirrors the page_size and page_token fields of list requests
+	PageRequest struct {
+		// PageSize is the maximum number of items, default 50, at most MaxPageSize
+		PageSize uint32
+		// PageToken is the NextPageToken of the previous page, empty for the first page
+		PageToken string
+		// Count runs a COUNT query to fill Page.TotalSize
+		Count bool
+	}
+
+	// Page is a page of items, it maps onto list responses with converter.PageToPb
+	Page[T any] struct {
+		Items []T
+		// TotalSize is the number of rows of the whole statement, -1 unless PageRequest.Count is set
+		TotalSize int64
+		HasNext   bool
+		// NextPageToken requests the following page, empty on the last page
+		NextPageToken string
+	}
+
+	// pageToken is base64 encoded JSON, keyset values are prefixed with their type to be decoded losslessly
+	pageToken struct {
+		Offset uint32   `json:"o,omitempty"`
+		After  []string `json:"k,omitempty"`
+	}
+)
+
+// SelectPage runs statement with offset pagination: limit + 1 rows are fetched to tell whether a next page exists.
+// The statement should be ordered so that pages are stable.
+func SelectPage[T any](ctx context.Context, helper DBHelper, statement string, request PageRequest,
+	agruments ...interface{}) (Page[T], error) {
+	page := Page[T]{TotalSize: -1}
+	token, err := decodePageToken(request.PageToken)
+	if err != nil {
+		return page, err
+	}
+	size := pageSize(request)
+	rows, err := helper.QueryRowsPagingContext(ctx, statement, token.Offset, fetchLimit(size), agruments)
+	if err != nil {
+		return page, err
+	}
+	if page.Items, err = ScanAll[T](rows); err != nil {
+		return page, err
+	}
+	if uint32(len(page.Items)) > size {
+		page.Items, page.HasNext = page.Items[:size], true
+		if token.Offset > math.MaxUint32-size {
+			return page, ErrInvalidPageToken
+		}
+		page.NextPageToken = encodePageToken(pageToken{Offset: token.Offset + size})
+	}
+	if request.Count {
+		page.TotalSize, err = helper.QueryCountContext(ctx, statement, agruments)
+	}
+	return page, err
+}
+
+// SelectKeysetPage runs statement with keyset pagination, see KeysetQuery. The token carries the keyset.Columns
+// values of the last item, which must be fields of T (matched as by ScanAll) or T itself for a single column.
+// keyset.After is ignored.
+func SelectKeysetPage[T any](ctx context.Context, helper DBHelper, statement string, keyset Keyset, request PageRequest,
+	agruments ...interface{}) (Page[T], error) {
+	page := Page[T]{TotalSize: -1}
+	token, err := decodePageToken(request.PageToken)
+	if err != nil {
+		return page, err
+	}
+	if keyset.After, err = decodeKeysetValues(token.After); err != nil {
+		return page, err
+	}
+	size := pageSize(request)
+	rows, err := helper.QueryRowsKeysetContext(ctx, statement, keyset, fetchLimit(size), agruments)
+	if err != nil {
+		return page, err
+	}
+	if page.Items, err = ScanAll[T](rows); err != nil {
+		return page, err
+	}
+	if uint32(len(page.Items)) > size {
+		page.Items, page.HasNext = page.Items[:size], true
+		after, err := encodeKeysetValues(page.Items[size-1], keyset.Columns)
+		if err != nil {
+			return page, err
+		}
+		page.NextPageToken = encodePageToken(pageToken{After: after})
+	}
+	if request.Count {
+		page.TotalSize, err = helper.QueryCountContext(ctx, statement, agruments)
+	}
+	return page, err
+}
+
+// CountQuery wraps statement in a COUNT query, its top level ORDER BY is removed
+func CountQuery(statement string) string {
+	if index := topLevelKeyword(statement, "ORDER BY"); index >= 0 {
+		statement = strings.TrimRight(statement[:index], " \t\r\n")
+	}
+	return "SELECT COUNT(*) FROM (" + statement + ") count_source"
+}
+
+func pageSize(request PageRequest) uint32 {
+	switch {
+	case request.PageSize == 0:
+		return defaultPageSize
+	case MaxPageSize > 0 && request.PageSize > MaxPageSize:
+		return MaxPageSize
+	}
+	return request.PageSize
+}
+
+// fetchLimit is size plus the row telling whether a next page exists
+func fetchLimit(size uint32) uint32 {
+	if size == math.MaxUint32 {
+		return size
+	}
+	return size + 1
+}
+
+func encodePageToken(token pageToken) string {
+	data, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(value string) (pageToken, error) {
+	var token pageToken
+	if value == "" {
+		return token, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return token, ErrInvalidPageToken
+	}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return token, ErrInvalidPageToken
+	}
+	return token, nil
+}
+
+// encodeKeysetValues reads the keyset columns of item
+func encodeKeysetValues(item interface{}, columns []string) ([]string, error) {
+	value := reflect.ValueOf(item)
+	result := make([]string, len(columns))
+	for i, column := range columns {
+		field := value
+		if isStruct(value.Type()) {
+			index, ok := fieldsOf(value.Type())[strings.ToLower(column)]
+			if !ok {
+				return nil, fmt.Errorf("db: no field of %s matches keyset column %s", value.Type(), column)
+			}
+			field = value.FieldByIndex(index)
+		} else if len(columns) > 1 {
+			return nil, fmt.Errorf("db: %s cannot hold the %d keyset columns", value.Type(), len(columns))
+		}
+		encoded, err := encodeKeysetValue(field.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("db: keyset column %s: %w", column, err)
+		}
+		result[i] = encoded
+	}
+	return result, nil
+}
+
+func encodeKeysetValue(value interface{}) (string, error) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		var err error
+		if value, err = valuer.Value(); err != nil {
+			return "", err
+		}
+	}
+	if value == nil {
+		return "n:", nil
+	}
+	if t, ok := value.(time.Time); ok {
+		return "t:" + t.Format(time.RFC3339Nano), nil
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return "n:", nil
+		}
+		return encodeKeysetValue(v.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "i:" + strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "u:" + strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return "f:" + strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case reflect.Bool:
+		return "b:" + strconv.FormatBool(v.Bool()), nil
+	case reflect.String:
+		return "s:" + v.String(), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return "x:" + base64.RawURLEncoding.EncodeToString(v.Bytes()), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %T", value)
+}
+
+func decodeKeysetValues(values []string) ([]interface{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		if len(value) < 2 || value[1] != ':' {
+			return nil, ErrInvalidPageToken
+		}
+		var err error
+		switch text := value[2:]; value[0] {
+		case 'n':
+			result[i] = nil
+		case 't':
+			result[i], err = time.Parse(time.RFC3339Nano, text)
+		case 'i':
+			result[i], err = strconv.ParseInt(text, 10, 64)
+		case 'u':
+			result[i], err = strconv.ParseUint(text, 10, 64)
+		case 'f':
+			result[i], err = strconv.ParseFloat(text, 64)
+		case 'b':
+			result[i], err = strconv.ParseBool(text)
+		case 's':
+			result[i] = text
+		case 'x':
+			result[i], err = base64.RawURLEncoding.DecodeString(text)
+		default:
+			err = ErrInvalidPageToken
+		}
+		if err != nil {
+			return nil, ErrInvalidPageToken
+		}
+	}
+	return result, nil
+}
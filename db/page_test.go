@@ -0,0 +1,160 @@
+package db
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCountQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+		want      string
+	}{
+		{
+			name:      "top level order",
+			statement: "SELECT * FROM t ORDER BY id",
+			want:      "SELECT COUNT(*) FROM (SELECT * FROM t) count_source",
+		},
+		{
+			name:      "lower case order",
+			statement: "select a from t order by a desc",
+			want:      "SELECT COUNT(*) FROM (select a from t) count_source",
+		},
+		{
+			name:      "nested order",
+			statement: "SELECT * FROM (SELECT * FROM t ORDER BY id LIMIT 1) x",
+			want:      "SELECT COUNT(*) FROM (SELECT * FROM (SELECT * FROM t ORDER BY id LIMIT 1) x) count_source",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CountQuery(tt.statement); got != tt.want {
+				t.Errorf("CountQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		token pageToken
+	}{
+		{name: "empty", token: pageToken{}},
+		{name: "offset", token: pageToken{Offset: 50}},
+		{name: "keyset", token: pageToken{After: []string{"s:a b", "i:-3"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodePageToken(encodePageToken(tt.token))
+			if err != nil {
+				t.Fatalf("decodePageToken() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.token) {
+				t.Errorf("decodePageToken() = %#v, want %#v", got, tt.token)
+			}
+		})
+	}
+}
+
+func TestDecodePageTokenInvalid(t *testing.T) {
+	for _, value := range []string{"not base64!", "bm90IGpzb24"} {
+		if _, err := decodePageToken(value); !errors.Is(err, ErrInvalidPageToken) {
+			t.Errorf("decodePageToken(%q) error = %v, want ErrInvalidPageToken", value, err)
+		}
+	}
+}
+
+func TestKeysetValues(t *testing.T) {
+	type item struct {
+		ID        int64
+		Name      string
+		Score     float64
+		Active    bool
+		Count     uint16
+		Data      []byte
+		CreatedAt time.Time
+		DeletedAt *time.Time
+	}
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	tests := []struct {
+		name    string
+		item    interface{}
+		columns []string
+		want    []interface{}
+	}{
+		{
+			name:    "struct fields",
+			item:    item{ID: -7, Name: "a:b", Score: 1.5, Active: true, Count: 3, Data: []byte{0, 255}, CreatedAt: createdAt},
+			columns: []string{"name", "score", "active", "count", "data", "created_at", "deleted_at", "id"},
+			want:    []interface{}{"a:b", 1.5, true, uint64(3), []byte{0, 255}, createdAt, nil, int64(-7)},
+		},
+		{
+			name:    "single value",
+			item:    "z",
+			columns: []string{"name"},
+			want:    []interface{}{"z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := encodeKeysetValues(tt.item, tt.columns)
+			if err != nil {
+				t.Fatalf("encodeKeysetValues() error = %v", err)
+			}
+			got, err := decodeKeysetValues(encoded)
+			if err != nil {
+				t.Fatalf("decodeKeysetValues() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeKeysetValues() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeysetValuesInvalid(t *testing.T) {
+	if _, err := encodeKeysetValues(struct{ ID int }{}, []string{"missing"}); err == nil {
+		t.Error("encodeKeysetValues() with an unknown column succeeded")
+	}
+	if _, err := encodeKeysetValues(1, []string{"a", "b"}); err == nil {
+		t.Error("encodeKeysetValues() of a scalar with two columns succeeded")
+	}
+	for _, value := range []string{"", "s", "q:x", "i:x", "t:yesterday"} {
+		if _, err := decodeKeysetValues([]string{value}); !errors.Is(err, ErrInvalidPageToken) {
+			t.Errorf("decodeKeysetValues(%q) error = %v, want ErrInvalidPageToken", value, err)
+		}
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      uint32
+		want      uint32
+		wantFetch uint32
+	}{
+		{name: "default", size: 0, want: defaultPageSize, wantFetch: defaultPageSize + 1},
+		{name: "requested", size: 20, want: 20, wantFetch: 21},
+		{name: "capped", size: MaxPageSize + 1, want: MaxPageSize, wantFetch: MaxPageSize + 1},
+		{name: "largest", size: math.MaxUint32, want: MaxPageSize, wantFetch: MaxPageSize + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pageSize(PageRequest{PageSize: tt.size})
+			if got != tt.want {
+				t.Errorf("pageSize() = %d, want %d", got, tt.want)
+			}
+			if fetch := fetchLimit(got); fetch != tt.wantFetch {
+				t.Errorf("fetchLimit() = %d, want %d", fetch, tt.wantFetch)
+			}
+		})
+	}
+	if got := fetchLimit(math.MaxUint32); got != math.MaxUint32 {
+		t.Errorf("fetchLimit(MaxUint32) = %d, want MaxUint32", got)
+	}
+}
@@ -0,0 +1,115 @@
+package db_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go-core/db"
+	"go-core/db/dbtest"
+)
+
+type pageItem struct {
+	ID   int64
+	Name string
+}
+
+// newPageHelper returns a SQLite helper filtering on deleted_at with items 1 to 5, item 3 is deleted
+func newPageHelper(t *testing.T) db.DBHelper {
+	helper, _ := dbtest.NewSQLite(t, db.DBOption{Key: "soft_delete_column", Value: "deleted_at"})
+	ctx := context.Background()
+	if _, err := helper.ExecContext(ctx, "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT, deleted_at TIMESTAMP)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := helper.ExecContext(ctx, "INSERT INTO items (id, name) VALUES (1, 'e'), (2, 'd'), (3, 'c'), (4, 'b'), (5, 'a')"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := helper.ExecContext(ctx, "UPDATE items SET deleted_at = CURRENT_TIMESTAMP WHERE id = 3"); err != nil {
+		t.Fatal(err)
+	}
+	return helper
+}
+
+func TestSelectPage(t *testing.T) {
+	helper := newPageHelper(t)
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantPages [][]int64
+		wantTotal int64
+	}{
+		{name: "skips deleted", ctx: context.Background(), wantPages: [][]int64{{1, 2}, {4, 5}}, wantTotal: 4},
+		{name: "include deleted", ctx: db.IncludeDeleted(context.Background()), wantPages: [][]int64{{1, 2}, {3, 4}, {5}}, wantTotal: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := db.PageRequest{PageSize: 2, Count: true}
+			var pages [][]int64
+			for {
+				page, err := db.SelectPage[pageItem](tt.ctx, helper, "SELECT id, name FROM items ORDER BY id", request)
+				if err != nil {
+					t.Fatalf("SelectPage() error = %v", err)
+				}
+				if page.TotalSize != tt.wantTotal {
+					t.Errorf("SelectPage() TotalSize = %d, want %d", page.TotalSize, tt.wantTotal)
+				}
+				pages = append(pages, itemIDs(page.Items))
+				if !page.HasNext {
+					break
+				}
+				request.PageToken = page.NextPageToken
+			}
+			if !reflect.DeepEqual(pages, tt.wantPages) {
+				t.Errorf("SelectPage() pages = %v, want %v", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestSelectKeysetPage(t *testing.T) {
+	helper := newPageHelper(t)
+	tests := []struct {
+		name      string
+		keyset    db.Keyset
+		wantPages [][]int64
+	}{
+		{name: "ascending name", keyset: db.Keyset{Columns: []string{"name", "id"}}, wantPages: [][]int64{{5, 4}, {2, 1}}},
+		{name: "descending id", keyset: db.Keyset{Columns: []string{"id"}, Descending: true}, wantPages: [][]int64{{5, 4}, {2, 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := db.PageRequest{PageSize: 2}
+			var pages [][]int64
+			for {
+				page, err := db.SelectKeysetPage[pageItem](context.Background(), helper, "SELECT id, name FROM items", tt.keyset, request)
+				if err != nil {
+					t.Fatalf("SelectKeysetPage() error = %v", err)
+				}
+				pages = append(pages, itemIDs(page.Items))
+				if !page.HasNext {
+					break
+				}
+				request.PageToken = page.NextPageToken
+			}
+			if !reflect.DeepEqual(pages, tt.wantPages) {
+				t.Errorf("SelectKeysetPage() pages = %v, want %v", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestSelectPageInvalidToken(t *testing.T) {
+	helper := newPageHelper(t)
+	_, err := db.SelectPage[pageItem](context.Background(), helper, "SELECT id, name FROM items", db.PageRequest{PageToken: "!"})
+	if err != db.ErrInvalidPageToken {
+		t.Errorf("SelectPage() error = %v, want ErrInvalidPageToken", err)
+	}
+}
+
+func itemIDs(items []pageItem) []int64 {
+	ids := make([]int64, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
@@ -0,0 +1,362 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "go-core/log"
+)
+
+const (
+	// queryStatsReservoir is the number of latency samples kept per statement
+	queryStatsReservoir = 512
+	// queryStatsMaxStatements bounds the distinct statements, the others are aggregated under queryStatsOther
+	queryStatsMaxStatements = 1000
+	queryStatsOther         = "<other>"
+)
+
+type (
+	// QueryStat aggregates the executions of a normalized statement. Query durations cover the
+	// statement execution up to the first row, not the iteration of the rows.
+	QueryStat struct {
+		Instance  string        `json:"instance"`
+		Statement string        `json:"statement"`
+		Count     int64         `json:"count"`
+		Errors    int64         `json:"errors"`
+		Total     time.Duration `json:"total"`
+		Max       time.Duration `json:"max"`
+		P50       time.Duration `json:"p50"`
+		P90       time.Duration `json:"p90"`
+		P99       time.Duration `json:"p99"`
+	}
+
+	// queryStats holds the statistics of a helper
+	queryStats struct {
+		mutex      sync.RWMutex
+		statements map[string]*statementStats
+	}
+
+	statementStats struct {
+		mutex   sync.Mutex
+		count   int64
+		errors  int64
+		total   time.Duration
+		max     time.Duration
+		samples []time.Duration
+	}
+
+	slowQuery struct {
+		Method    string                 `json:"method"`
+		Statement string                 `json:"statement"`
+		Duration  string                 `json:"duration"`
+		Caller    string                 `json:"caller"`
+		Arguments map[string]interface{} `json:"arguments,omitempty"`
+		Error     string                 `json:"error,omitempty"`
+	}
+)
+
+// redactedArgument replaces the slow query arguments whose column is unknown
+const redactedArgument = "[redacted]"
+
+func newQueryStats() *queryStats {
+	return &queryStats{statements: map[string]*statementStats{}}
+}
+
+// record adds an execution of the normalized statement
+func (s *queryStats) record(statement string, duration time.Duration, err error) {
+	s.mutex.RLock()
+	stats, ok := s.statements[statement]
+	s.mutex.RUnlock()
+	if !ok {
+		s.mutex.Lock()
+		if stats, ok = s.statements[statement]; !ok {
+			if len(s.statements) >= queryStatsMaxStatements {
+				statement = queryStatsOther
+				stats = s.statements[statement]
+			}
+			if stats == nil {
+				stats = &statementStats{}
+				s.statements[statement] = stats
+			}
+		}
+		s.mutex.Unlock()
+	}
+
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+	stats.count++
+	if err != nil {
+		stats.errors++
+	}
+	stats.total += duration
+	if duration > stats.max {
+		stats.max = duration
+	}
+	// reservoir sampling keeps a uniform sample of every execution
+	if len(stats.samples) < queryStatsReservoir {
+		stats.samples = append(stats.samples, duration)
+	} else if i := rand.Int63n(stats.count); i < queryStatsReservoir {
+		stats.samples[i] = duration
+	}
+}
+
+// snapshot returns the statistics sorted by total duration, the most expensive first
+func (s *queryStats) snapshot(instance string) []QueryStat {
+	s.mutex.RLock()
+	result := make([]QueryStat, 0, len(s.statements))
+	for statement, stats := range s.statements {
+		stats.mutex.Lock()
+		samples := make([]time.Duration, len(stats.samples))
+		copy(samples, stats.samples)
+		stat := QueryStat{
+			Instance:  instance,
+			Statement: statement,
+			Count:     stats.count,
+			Errors:    stats.errors,
+			Total:     stats.total,
+			Max:       stats.max,
+		}
+		stats.mutex.Unlock()
+
+		sort.Slice(samples, func(i, j int) bool {
+			return samples[i] < samples[j]
+		})
+		stat.P50, stat.P90, stat.P99 = percentile(samples, 50), percentile(samples, 90), percentile(samples, 99)
+		result = append(result, stat)
+	}
+	s.mutex.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Total > result[j].Total
+	})
+	return result
+}
+
+func (s *queryStats) reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.statements = map[string]*statementStats{}
+}
+
+// percentile uses the nearest rank of sorted samples
+func percentile(samples []time.Duration, p int) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	rank := (p*len(samples) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return samples[rank-1]
+}
+
+// observe records an execution and logs it when it exceeds slow_query_threshold
+func (h *baseDBHelper) observe(method, statement string, agruments []interface{}, start time.Time, err error) {
+	if h.queryStats == nil && h.slowQueryThreshold <= 0 {
+		return
+	}
+	duration := time.Since(start)
+	normalized := normalizeStatement(statement)
+	if h.queryStats != nil {
+		h.queryStats.record(normalized, duration, err)
+	}
+	if h.slowQueryThreshold <= 0 || duration < h.slowQueryThreshold {
+		return
+	}
+
+	entry := slowQuery{
+		Method:    method,
+		Statement: normalized,
+		Duration:  duration.String(),
+		Caller:    caller(),
+	}
+	if len(agruments) > 0 {
+		names, unnamed := argumentNames(h.dialect, statement, len(agruments))
+		entry.Arguments = make(map[string]interface{}, len(agruments))
+		for i, agrument := range agruments {
+			// without a column name the masking rules cannot recognize a sensitive value
+			if unnamed[i] && !h.slowQueryUnnamedArguments {
+				agrument = redactedArgument
+			}
+			entry.Arguments[names[i]] = agrument
+		}
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	// arguments are keyed by column name so the JSON masking rules apply to them
+	log.Logger.L.Warn("Slow query", log.Object("query", entry))
+}
+
+// QueryStats returns the per statement statistics sorted by total duration
+func (h *baseDBHelper) QueryStats() []QueryStat {
+	if h.queryStats == nil {
+		return nil
+	}
+	return h.queryStats.snapshot(h.config.Address())
+}
+
+// ResetQueryStats clears the statistics
+func (h *baseDBHelper) ResetQueryStats() {
+	if h.queryStats != nil {
+		h.queryStats.reset()
+	}
+}
+
+// QueryStatsHandler serves the statistics of helper as JSON for a debug endpoint, POST resets them
+func QueryStatsHandler(helper DBHelper) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			helper.ResetQueryStats()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(helper.QueryStats())
+	})
+}
+
+// caller returns the first frame outside of the db package
+func caller() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "go-core/db.") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// argumentNames infers the column bound to every argument from the statement: the column compared
+// to the placeholder (name = $1, name IN (?, ?)) or the column list of an INSERT. Unknown arguments
+// are named argN (1-based) and flagged as unnamed, repeated columns get a _2, _3 suffix.
+func argumentNames(dialect Dialect, statement string, count int) (names []string, unnamed []bool) {
+	names, unnamed = make([]string, count), make([]bool, count)
+	prefix := strings.TrimSuffix(dialect.Placeholder(1), "1")
+	columns, values := insertColumnList(statement)
+	sequence, column := 0, 0
+
+	for i := 0; i < len(statement); i++ {
+		switch c := statement[i]; {
+		case c == '\'':
+			for i++; i < len(statement) && statement[i] != '\''; i++ {
+			}
+			continue
+		case values >= 0 && i > values && c == '(':
+			column = 0
+		case values >= 0 && i > values && c == ',':
+			column++
+		}
+		if !strings.HasPrefix(statement[i:], prefix) {
+			continue
+		}
+
+		index, end := sequence, i+len(prefix)
+		if prefix == "?" {
+			sequence++
+		} else {
+			for end < len(statement) && statement[end] >= '0' && statement[end] <= '9' {
+				end++
+			}
+			if end == i+len(prefix) {
+				continue
+			}
+			number, _ := strconv.Atoi(statement[i+len(prefix) : end])
+			index = number - 1
+		}
+		if index >= 0 && index < count && names[index] == "" {
+			if values >= 0 && i > values {
+				names[index] = columns[column%len(columns)]
+			} else {
+				names[index] = comparedColumn(statement[:i])
+			}
+		}
+		i = end - 1
+	}
+
+	seen := make(map[string]int, count)
+	for i, name := range names {
+		if name == "" {
+			name, unnamed[i] = fmt.Sprintf("arg%d", i+1), true
+		}
+		if seen[name]++; seen[name] > 1 {
+			name = fmt.Sprintf("%s_%d", name, seen[name])
+		}
+		names[i] = name
+	}
+	return names, unnamed
+}
+
+// comparedColumn returns the column compared at the end of prefix, e.g. "email" for "WHERE u.email = ",
+// previous placeholders of an IN list and comparison keywords are skipped
+func comparedColumn(prefix string) string {
+	end := len(prefix)
+	for end > 0 {
+		for end > 0 && strings.IndexByte(" \t\r\n=<>!(,", prefix[end-1]) >= 0 {
+			end--
+		}
+		start := end
+		for start > 0 && (isWordByte(prefix[start-1]) || strings.IndexByte(".$:@?", prefix[start-1]) >= 0) {
+			start--
+		}
+		token := prefix[start:end]
+		switch {
+		case token == "":
+			return ""
+		case strings.IndexByte("$:@?", token[0]) >= 0:
+		case comparisonKeywords[strings.ToLower(token)]:
+		case clauseKeywords[strings.ToLower(token)]:
+			return ""
+		default:
+			if dot := strings.LastIndexByte(token, '.'); dot >= 0 {
+				token = token[dot+1:]
+			}
+			if token == "" || (token[0] >= '0' && token[0] <= '9') {
+				return ""
+			}
+			return strings.ToLower(token)
+		}
+		end = start
+	}
+	return ""
+}
+
+var (
+	comparisonKeywords = map[string]bool{"like": true, "ilike": true, "in": true, "not": true, "is": true}
+	clauseKeywords     = map[string]bool{"select": true, "where": true, "and": true, "or": true, "on": true, "set": true,
+		"having": true, "when": true, "then": true, "else": true, "between": true, "return": true}
+)
+
+// insertColumnList returns the column list of an INSERT INTO table (columns) VALUES statement
+// and the position of VALUES, -1 for other statements
+func insertColumnList(statement string) ([]string, int) {
+	trimmed := strings.TrimLeft(statement, " \t\r\n")
+	if len(trimmed) < 6 || !strings.EqualFold(trimmed[:6], "insert") {
+		return nil, -1
+	}
+	offset := len(statement) - len(trimmed)
+	open := strings.IndexByte(trimmed, '(')
+	values := strings.Index(strings.ToLower(trimmed), "values")
+	if open < 0 || values < 0 || open > values {
+		return nil, -1
+	}
+	closing := strings.IndexByte(trimmed[open:], ')')
+	if closing < 0 {
+		return nil, -1
+	}
+	columns := strings.Split(trimmed[open+1:open+closing], ",")
+	for i, column := range columns {
+		columns[i] = strings.ToLower(strings.Trim(strings.TrimSpace(column), "\"`[]"))
+	}
+	return columns, offset + values
+}
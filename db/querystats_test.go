@@ -0,0 +1,73 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestArgumentNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		dialect   Dialect
+		statement string
+		count     int
+		want      []string
+	}{
+		{
+			name:      "comparison and in list",
+			dialect:   PostgresDialect,
+			statement: "SELECT * FROM users WHERE u.email = $1 AND id IN ($2, $3)",
+			count:     3,
+			want:      []string{"email", "id", "id_2"},
+		},
+		{
+			name:      "range and like",
+			dialect:   PostgresDialect,
+			statement: "SELECT * FROM t WHERE a >= $1 AND a <= $2 AND b LIKE $3",
+			count:     3,
+			want:      []string{"a", "a_2", "b"},
+		},
+		{
+			name:      "insert rows",
+			dialect:   MySQLDialect,
+			statement: "INSERT INTO users (name, email) VALUES (?, ?), (?, ?)",
+			count:     4,
+			want:      []string{"name", "email", "name_2", "email_2"},
+		},
+		{
+			name:      "numbered out of order",
+			dialect:   OracleDialect,
+			statement: "SELECT * FROM t WHERE a = :2 AND b = :1",
+			count:     2,
+			want:      []string{"b", "a"},
+		},
+		{
+			name:      "unbound argument",
+			dialect:   SQLServerDialect,
+			statement: "UPDATE t SET a = @p1 WHERE id = @p2",
+			count:     3,
+			want:      []string{"a", "id", "arg3"},
+		},
+		{
+			name:      "function call",
+			dialect:   SQLiteDialect,
+			statement: "SELECT * FROM t WHERE a = ? OR a = ? OR upper(b) = ?",
+			count:     3,
+			want:      []string{"a", "a_2", "arg3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, unnamed := argumentNames(tt.dialect, tt.statement, tt.count)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("argumentNames() = %v, want %v", got, tt.want)
+			}
+			for i, name := range tt.want {
+				if want := strings.HasPrefix(name, "arg"); unnamed[i] != want {
+					t.Errorf("argumentNames() unnamed[%d] = %v, want %v", i, unnamed[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "go-core/log"
+)
+
+const (
+	defaultReplicaHealthInterval = 5 * time.Second
+
+	// a replica which replayed everything it received is caught up, the replay timestamp only
+	// tells the lag while WAL is pending since it does not move when the primary is idle
+	postgresReplicaLagQuery = "SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0 " +
+		"ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END"
+)
+
+type (
+	primaryContextKey struct{}
+
+	replica struct {
+		helper  DBHelper
+		healthy int32
+	}
+
+	// replicatedDBHelper routes reads to replicas and writes and transactions to the primary
+	replicatedDBHelper struct {
+		primary  DBHelper
+		replicas []*replica
+
+		leastConnections bool
+		next             uint32
+		healthInterval   time.Duration
+		maxLag           time.Duration
+		lagQuery         string
+
+		stop      chan struct{}
+		closeOnce sync.Once
+	}
+)
+
+// ForcePrimary sends the queries made with the returned context to the primary. Replicas apply the writes
+// of the primary asynchronously, use it to read your own writes right after committing them.
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryContextKey{}, true)
+}
+
+func isPrimaryForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(primaryContextKey{}).(bool)
+	return forced
+}
+
+// NewReplicatedDBHelper creates an instance routing reads to replicas. Replicas are health checked in the
+// background and ejected while they fail or lag behind, reads fall back to the primary when none is healthy.
+// Only plain SELECT statements made outside of WithTxContext and ForcePrimary are reads, e.g. INSERT ... RETURNING
+// and SELECT ... FOR UPDATE run on the primary.
+//
+//	replica_balancer        string, "round_robin" (default) or "least_connections"
+//	replica_health_interval time.Duration, default 5s
+//	replica_max_lag         time.Duration, ejects replicas lagging more, zero disables the lag check
+//	replica_lag_query       string, returns the lag in seconds, defaults to pg_last_xact_replay_timestamp on postgres
+func NewReplicatedDBHelper(primary DBHelper, replicas []DBHelper, opts ...DBOption) DBHelper {
+	helper := &replicatedDBHelper{
+		primary:        primary,
+		replicas:       make([]*replica, len(replicas)),
+		healthInterval: defaultReplicaHealthInterval,
+		stop:           make(chan struct{}),
+	}
+	if primary.Dialect().Name() == PostgresDialect.Name() {
+		helper.lagQuery = postgresReplicaLagQuery
+	}
+	for _, item := range opts {
+		switch item.Key {
+		case "replica_balancer":
+			helper.leastConnections = item.Value.(string) == "least_connections"
+		case "replica_health_interval":
+			helper.healthInterval = item.Value.(time.Duration)
+		case "replica_max_lag":
+			helper.maxLag = item.Value.(time.Duration)
+		case "replica_lag_query":
+			helper.lagQuery = item.Value.(string)
+		}
+	}
+	for i, item := range replicas {
+		helper.replicas[i] = &replica{helper: item, healthy: 1}
+	}
+	if len(replicas) > 0 {
+		// replicas failing the first check are ejected before serving any read
+		helper.checkReplicas()
+		go helper.healthCheck()
+	}
+	return helper
+}
+
+func (h *replicatedDBHelper) healthCheck() {
+	ticker := time.NewTicker(h.healthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+			h.checkReplicas()
+		}
+	}
+}
+
+// checkReplicas updates the health of every replica
+func (h *replicatedDBHelper) checkReplicas() {
+	for index, item := range h.replicas {
+		err := h.checkReplica(item)
+		healthy := int32(1)
+		if err != nil {
+			healthy = 0
+		}
+		if previous := atomic.SwapInt32(&item.healthy, healthy); previous != healthy {
+			if err != nil {
+				log.Logger.Warnw("Ejected DB replica", "replica", index, "error", err)
+			} else {
+				log.Logger.Infow("Restored DB replica", "replica", index)
+			}
+		}
+	}
+}
+
+func (h *replicatedDBHelper) checkReplica(item *replica) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.healthInterval)
+	defer cancel()
+	if err := item.helper.Open().PingContext(ctx); err != nil {
+		return err
+	}
+	if h.maxLag <= 0 || h.lagQuery == "" {
+		return nil
+	}
+	var lagSeconds float64
+	if err := item.helper.QueryRowContext(ctx, h.lagQuery).Scan(&lagSeconds); err != nil {
+		return err
+	}
+	if lag := time.Duration(lagSeconds * float64(time.Second)); lag > h.maxLag {
+		return &ReplicaLagError{Lag: lag, MaxLag: h.maxLag}
+	}
+	return nil
+}
+
+// ReplicaLagError is reported when a replica lags behind the primary more than replica_max_lag
+type ReplicaLagError struct {
+	Lag    time.Duration
+	MaxLag time.Duration
+}
+
+func (e *ReplicaLagError) Error() string {
+	return "replica lag " + e.Lag.String() + " exceeds " + e.MaxLag.String()
+}
+
+// reader returns the helper serving reads made with ctx
+func (h *replicatedDBHelper) reader(ctx context.Context, statement string) DBHelper {
+	if isPrimaryForced(ctx) || len(h.replicas) == 0 || !isReadStatement(statement) {
+		return h.primary
+	}
+	// the transactions of WithTxContext run on the primary, reads made within should see their writes
+	if _, inTx := TxFromContext(ctx); inTx {
+		return h.primary
+	}
+	if h.leastConnections {
+		var (
+			selected *replica
+			inUse    int
+		)
+		for _, item := range h.replicas {
+			if atomic.LoadInt32(&item.healthy) == 0 {
+				continue
+			}
+			if current := item.helper.Open().Stats().InUse; selected == nil || current < inUse {
+				selected, inUse = item, current
+			}
+		}
+		if selected == nil {
+			return h.primary
+		}
+		return selected.helper
+	}
+
+	start := atomic.AddUint32(&h.next, 1)
+	for i := 0; i < len(h.replicas); i++ {
+		item := h.replicas[(int(start)+i)%len(h.replicas)]
+		if atomic.LoadInt32(&item.healthy) == 1 {
+			return item.helper
+		}
+	}
+	return h.primary
+}
+
+// Open returns the primary pool
+func (h *replicatedDBHelper) Open() *sql.DB {
+	return h.primary.Open()
+}
+
+func (h *replicatedDBHelper) Close() error {
+	h.closeOnce.Do(func() {
+		close(h.stop)
+	})
+	err := h.primary.Close()
+	for _, item := range h.replicas {
+		if errClose := item.helper.Close(); err == nil {
+			err = errClose
+		}
+	}
+	return err
+}
+
+// Ready follows the primary, replicas are ejected by the health check until they are reachable
+func (h *replicatedDBHelper) Ready() bool {
+	return h.primary.Ready()
+}
+
+// HealthCheck checks the primary, failed replicas are ejected instead of failing the check
+func (h *replicatedDBHelper) HealthCheck(ctx context.Context) error {
+	return h.primary.HealthCheck(ctx)
+}
+
+// QueryStats concatenates the statistics of the primary and the replicas, QueryStat.Instance tells them apart
+func (h *replicatedDBHelper) QueryStats() []QueryStat {
+	stats := h.primary.QueryStats()
+	for _, item := range h.replicas {
+		stats = append(stats, item.helper.QueryStats()...)
+	}
+	return stats
+}
+
+func (h *replicatedDBHelper) ResetQueryStats() {
+	h.primary.ResetQueryStats()
+	for _, item := range h.replicas {
+		item.helper.ResetQueryStats()
+	}
+}
+
+func (h *replicatedDBHelper) Dialect() Dialect {
+	return h.primary.Dialect()
+}
+
+func (h *replicatedDBHelper) Begin() (*sql.Tx, error) {
+	return h.primary.Begin()
+}
+
+func (h *replicatedDBHelper) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return h.primary.BeginTx(ctx, opts)
+}
+
+func (h *replicatedDBHelper) Commit(tx *sql.Tx) error {
+	return h.primary.Commit(tx)
+}
+
+func (h *replicatedDBHelper) Rollback(tx *sql.Tx) error {
+	return h.primary.Rollback(tx)
+}
+
+func (h *replicatedDBHelper) WithTx(ctx context.Context, opts *sql.TxOptions, fn TxFunc) error {
+	return h.primary.WithTx(ctx, opts, fn)
+}
+
+func (h *replicatedDBHelper) WithTxContext(ctx context.Context, opts *sql.TxOptions, fn TxContextFunc) error {
+	return h.primary.WithTxContext(ctx, opts, fn)
+}
+
+func (h *replicatedDBHelper) ExecContext(ctx context.Context, statement string, agruments ...interface{}) (sql.Result, error) {
+	return h.primary.ExecContext(ctx, statement, agruments...)
+}
+
+func (h *replicatedDBHelper) QueryContext(ctx context.Context, statement string, agruments ...interface{}) (*sql.Rows, error) {
+	return h.reader(ctx, statement).QueryContext(ctx, statement, agruments...)
+}
+
+func (h *replicatedDBHelper) QueryRowContext(ctx context.Context, statement string, agruments ...interface{}) *sql.Row {
+	return h.reader(ctx, statement).QueryRowContext(ctx, statement, agruments...)
+}
+
+func (h *replicatedDBHelper) QueryRowsPaging(statement string, offset, limit uint32, agruments []interface{}) (*sql.Rows, error) {
+	return h.QueryRowsPagingContext(context.Background(), statement, offset, limit, agruments)
+}
+
+func (h *replicatedDBHelper) QueryRowPaging(statement string, offset, limit uint32, agruments []interface{}) *sql.Row {
+	return h.QueryRowPagingContext(context.Background(), statement, offset, limit, agruments)
+}
+
+func (h *replicatedDBHelper) QueryRowsPagingContext(ctx context.Context, statement string, offset, limit uint32, agruments []interface{}) (*sql.Rows, error) {
+	return h.reader(ctx, statement).QueryRowsPagingContext(ctx, statement, offset, limit, agruments)
+}
+
+func (h *replicatedDBHelper) QueryRowPagingContext(ctx context.Context, statement string, offset, limit uint32, agruments []interface{}) *sql.Row {
+	return h.reader(ctx, statement).QueryRowPagingContext(ctx, statement, offset, limit, agruments)
+}
+
+func (h *replicatedDBHelper) QueryRowsKeyset(statement string, keyset Keyset, limit uint32, agruments []interface{}) (*sql.Rows, error) {
+	return h.QueryRowsKeysetContext(context.Background(), statement, keyset, limit, agruments)
+}
+
+func (h *replicatedDBHelper) QueryRowsKeysetContext(ctx context.Context, statement string, keyset Keyset, limit uint32, agruments []interface{}) (*sql.Rows, error) {
+	return h.reader(ctx, statement).QueryRowsKeysetContext(ctx, statement, keyset, limit, agruments)
+}
+
+func (h *replicatedDBHelper) QueryCountContext(ctx context.Context, statement string, agruments []interface{}) (int64, error) {
+	return h.reader(ctx, statement).QueryCountContext(ctx, statement, agruments)
+}
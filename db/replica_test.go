@@ -0,0 +1,75 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"go-core/db"
+	"go-core/db/dbtest"
+)
+
+func TestReplicatedDBHelperRouting(t *testing.T) {
+	primary, primaryFake := dbtest.NewFake(t, db.PostgresDialect)
+	replica, replicaFake := dbtest.NewFake(t, db.PostgresDialect)
+	helper := db.NewReplicatedDBHelper(primary, []db.DBHelper{replica})
+	t.Cleanup(func() {
+		_ = helper.Close()
+	})
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		statement   string
+		wantPrimary bool
+	}{
+		{name: "select", ctx: context.Background(), statement: "SELECT id FROM users"},
+		{name: "select after a comment", ctx: context.Background(), statement: "/* users */ select id from users"},
+		{name: "forced primary", ctx: db.ForcePrimary(context.Background()), statement: "SELECT id FROM users", wantPrimary: true},
+		{name: "insert returning", ctx: context.Background(), statement: "INSERT INTO users (name) VALUES ($1) RETURNING id", wantPrimary: true},
+		{name: "update returning", ctx: context.Background(), statement: "UPDATE users SET name = $1 RETURNING id", wantPrimary: true},
+		{name: "common table expression", ctx: context.Background(), statement: "WITH d AS (DELETE FROM users RETURNING id) SELECT * FROM d", wantPrimary: true},
+		{name: "locking select", ctx: context.Background(), statement: "SELECT id FROM users WHERE id = $1 FOR UPDATE", wantPrimary: true},
+		{name: "select into", ctx: context.Background(), statement: "SELECT id INTO archive FROM users", wantPrimary: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primaryFake.Reset()
+			replicaFake.Reset()
+			var id int64
+			if err := helper.QueryRowContext(tt.ctx, tt.statement, "a").Scan(&id); err != sql.ErrNoRows {
+				t.Fatalf("QueryRowContext() error = %v", err)
+			}
+			onPrimary, onReplica := len(primaryFake.Matching(tt.statement)), len(replicaFake.Matching(tt.statement))
+			if tt.wantPrimary && (onPrimary != 1 || onReplica != 0) {
+				t.Errorf("statement ran %d times on the primary and %d on the replica, want the primary", onPrimary, onReplica)
+			}
+			if !tt.wantPrimary && (onPrimary != 0 || onReplica != 1) {
+				t.Errorf("statement ran %d times on the primary and %d on the replica, want the replica", onPrimary, onReplica)
+			}
+		})
+	}
+}
+
+func TestReplicatedDBHelperTxContext(t *testing.T) {
+	primary, primaryFake := dbtest.NewFake(t, db.PostgresDialect)
+	replica, replicaFake := dbtest.NewFake(t, db.PostgresDialect)
+	helper := db.NewReplicatedDBHelper(primary, []db.DBHelper{replica})
+	t.Cleanup(func() {
+		_ = helper.Close()
+	})
+
+	err := helper.WithTxContext(context.Background(), nil, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := helper.QueryContext(ctx, "SELECT id FROM users")
+		if err != nil {
+			return err
+		}
+		return rows.Close()
+	})
+	if err != nil {
+		t.Fatalf("WithTxContext() error = %v", err)
+	}
+	if len(primaryFake.Matching("SELECT id FROM users")) != 1 || len(replicaFake.Matching("SELECT id FROM users")) != 0 {
+		t.Error("read made within WithTxContext did not run on the primary")
+	}
+}
@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ErrTooManyRows is returned by Get when the query returns more than one row
+var ErrTooManyRows = errors.New("sql: more than one row in result set")
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	// structFields caches the column to field index mapping per struct type
+	structFields sync.Map
+)
+
+// SelectAll runs statement and maps every row to T, see ScanAll
+func SelectAll[T any](ctx context.Context, helper DBHelper, statement string, agruments ...interface{}) ([]T, error) {
+	rows, err := helper.QueryContext(ctx, statement, agruments...)
+	if err != nil {
+		return nil, err
+	}
+	return ScanAll[T](rows)
+}
+
+// SelectOne runs statement and maps the first row to T, sql.ErrNoRows is returned when there is none
+func SelectOne[T any](ctx context.Context, helper DBHelper, statement string, agruments ...interface{}) (T, error) {
+	rows, err := helper.QueryContext(ctx, statement, agruments...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return ScanOne[T](rows)
+}
+
+// Get runs statement and maps its single row to T, sql.ErrNoRows is returned when there is no row
+// and ErrTooManyRows when there are several
+func Get[T any](ctx context.Context, helper DBHelper, statement string, agruments ...interface{}) (T, error) {
+	var zero T
+	rows, err := helper.QueryContext(ctx, statement, agruments...)
+	if err != nil {
+		return zero, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return zero, err
+		}
+		return zero, sql.ErrNoRows
+	}
+	item, err := scanRow[T](rows)
+	if err != nil {
+		return zero, err
+	}
+	if rows.Next() {
+		return zero, ErrTooManyRows
+	}
+	return item, rows.Err()
+}
+
+// ScanAll maps every row to T and closes rows. Struct fields are matched to columns case insensitively
+// by their db tag or their snake_cased name, `db:"-"` skips a field and embedded structs are flattened.
+// Any other T (scalars, sql.Scanner, pointers) receives the single column of the result.
+func ScanAll[T any](rows *sql.Rows) ([]T, error) {
+	defer rows.Close()
+	var result []T
+	for rows.Next() {
+		item, err := scanRow[T](rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, rows.Err()
+}
+
+// ScanOne maps the first row to T and closes rows, sql.ErrNoRows is returned when there is none
+func ScanOne[T any](rows *sql.Rows) (T, error) {
+	defer rows.Close()
+	var zero T
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return zero, err
+		}
+		return zero, sql.ErrNoRows
+	}
+	item, err := scanRow[T](rows)
+	if err != nil {
+		return zero, err
+	}
+	return item, rows.Close()
+}
+
+func scanRow[T any](rows *sql.Rows) (T, error) {
+	var item T
+	value := reflect.ValueOf(&item).Elem()
+	if !isStruct(value.Type()) {
+		return item, rows.Scan(&item)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return item, err
+	}
+	fields := fieldsOf(value.Type())
+	destinations := make([]interface{}, len(columns))
+	for i, column := range columns {
+		index, ok := fields[strings.ToLower(column)]
+		if !ok {
+			return item, fmt.Errorf("db: no field of %s matches column %s", value.Type(), column)
+		}
+		destinations[i] = fieldByIndex(value, index).Addr().Interface()
+	}
+	return item, rows.Scan(destinations...)
+}
+
+// isStruct reports whether t is mapped field by field rather than scanned as a single value
+func isStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !reflect.PtrTo(t).Implements(scannerType) && t.PkgPath() != "time"
+}
+
+// fieldsOf returns the lower cased column names of t mapped to their field index path
+func fieldsOf(t reflect.Type) map[string][]int {
+	if cached, ok := structFields.Load(t); ok {
+		return cached.(map[string][]int)
+	}
+	fields := make(map[string][]int)
+	collectFields(t, nil, fields)
+	structFields.Store(t, fields)
+	return fields
+}
+
+func collectFields(t reflect.Type, parent []int, fields map[string][]int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+		index := append(append([]int{}, parent...), i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			// the fields behind an embedded pointer to an unexported struct cannot be set
+			if field.Anonymous && !field.IsExported() {
+				continue
+			}
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && tag == "" && isStruct(fieldType) {
+			collectFields(fieldType, index, fields)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name := tag
+		if name == "" {
+			name = snakeCase(field.Name)
+		}
+		// fields of the outer struct win over embedded ones
+		if _, ok := fields[strings.ToLower(name)]; !ok || len(index) < len(fields[strings.ToLower(name)]) {
+			fields[strings.ToLower(name)] = index
+		}
+	}
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex allocating nil embedded pointers on the way
+func fieldByIndex(value reflect.Value, index []int) reflect.Value {
+	for i, position := range index {
+		if i > 0 && value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		value = value.Field(position)
+	}
+	return value
+}
+
+// snakeCase converts UserID to user_id and CreatedAt to created_at
+func snakeCase(name string) string {
+	runes := []rune(name)
+	builder := strings.Builder{}
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			previousLower := i > 0 && !unicode.IsUpper(runes[i-1])
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if previousLower || nextLower {
+				builder.WriteByte('_')
+			}
+			builder.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
@@ -0,0 +1,43 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"go-core/db"
+	"go-core/db/dbtest"
+)
+
+type audit struct {
+	CreatedBy string
+}
+
+type Owner struct {
+	OwnerID int64
+}
+
+type scannedUser struct {
+	*audit
+	*Owner
+	ID   int64
+	Name string
+}
+
+func TestSelectAllEmbeddedPointers(t *testing.T) {
+	helper, fake := dbtest.NewFake(t, db.PostgresDialect)
+	fake.StubQuery("SELECT id, name, owner_id FROM users", []string{"id", "name", "owner_id"}, []interface{}{int64(1), "a", int64(2)})
+
+	users, err := db.SelectAll[scannedUser](context.Background(), helper, "SELECT id, name, owner_id FROM users")
+	if err != nil {
+		t.Fatalf("SelectAll() error = %v", err)
+	}
+	if len(users) != 1 || users[0].ID != 1 || users[0].Name != "a" || users[0].Owner == nil || users[0].OwnerID != 2 {
+		t.Errorf("SelectAll() = %+v", users)
+	}
+
+	// the fields behind the unexported embedded pointer are not mapped
+	fake.StubQuery("SELECT created_by FROM users", []string{"created_by"}, []interface{}{"a"})
+	if _, err := db.SelectAll[scannedUser](context.Background(), helper, "SELECT created_by FROM users"); err == nil {
+		t.Error("SelectAll() error = nil, want an unmatched column")
+	}
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"context"
+	"strings"
+)
+
+type includeDeletedKey struct{}
+
+var (
+	// softDeleteClauseEnds end the FROM and WHERE clauses of the first SELECT of a statement
+	softDeleteClauseEnds = []string{"GROUP BY", "HAVING", "WINDOW", "ORDER BY", "LIMIT", "OFFSET", "FETCH", "FOR",
+		"LOCK", "UNION", "INTERSECT", "EXCEPT", "MINUS"}
+	// softDeleteAliasStops are the words which may follow the first FROM item instead of an alias
+	softDeleteAliasStops = map[string]bool{"where": true, "join": true, "inner": true, "left": true, "right": true,
+		"full": true, "cross": true, "outer": true, "natural": true, "on": true, "using": true, "with": true,
+		"group": true, "having": true, "window": true, "order": true, "limit": true, "offset": true, "fetch": true,
+		"for": true, "lock": true, "union": true, "intersect": true, "except": true, "minus": true}
+)
+
+// IncludeDeleted returns a context whose paging queries are not filtered on the soft_delete_column
+func IncludeDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+// softDeleteFilter applies the soft_delete_column option to a paging statement
+func (h *baseDBHelper) softDeleteFilter(ctx context.Context, statement string) string {
+	if h.softDeleteColumn == "" {
+		return statement
+	}
+	if include, _ := ctx.Value(includeDeletedKey{}).(bool); include {
+		return statement
+	}
+	return SoftDeleteFilter(statement, h.softDeleteColumn)
+}
+
+// SoftDeleteFilter adds "<first FROM item>.column IS NULL" to the top level WHERE clause of the first SELECT
+// of statement, the existing condition is parenthesized. Statements whose WHERE clause already refers to column,
+// e.g. to list deleted rows, and statements whose first FROM item is a subquery without alias or not selecting
+// column are returned unchanged.
+func SoftDeleteFilter(statement, column string) string {
+	if column == "" {
+		return statement
+	}
+	from := firstTopLevelKeyword(statement, 0, "FROM")
+	if from < 0 {
+		return statement
+	}
+	qualifier, subquery := fromQualifier(statement, from+len("FROM"))
+	if qualifier == "" || (subquery != "" && !selectsColumn(subquery, column)) {
+		return statement
+	}
+	condition := qualifier + "." + column + " IS NULL"
+
+	end := firstTopLevelKeyword(statement, from, softDeleteClauseEnds...)
+	suffix := ""
+	if end < 0 {
+		end = len(statement)
+	} else {
+		suffix = " " + statement[end:]
+	}
+	if where := firstTopLevelKeyword(statement[:end], from, "WHERE"); where >= 0 {
+		body := strings.TrimSpace(statement[where+len("WHERE") : end])
+		if containsWord(body, column) {
+			return statement
+		}
+		return statement[:where] + "WHERE " + condition + " AND (" + body + ")" + suffix
+	}
+	return strings.TrimRight(statement[:end], " \t\r\n") + " WHERE " + condition + suffix
+}
+
+// selectsColumn reports whether the select list of subquery has column or a star
+func selectsColumn(subquery, column string) bool {
+	from := firstTopLevelKeyword(subquery, 0, "FROM")
+	if from < 0 {
+		return false
+	}
+	projection := subquery[:from]
+	if start := firstTopLevelKeyword(projection, 0, "SELECT"); start >= 0 {
+		projection = projection[start+len("SELECT"):]
+	}
+	for _, item := range strings.Split(projection, ",") {
+		if item = strings.TrimSpace(item); item == "*" || strings.HasSuffix(item, ".*") {
+			return true
+		}
+	}
+	return containsWord(projection, column)
+}
+
+// firstTopLevelKeyword returns the index of the first top level occurrence of any keyword at or after from, or -1
+func firstTopLevelKeyword(statement string, from int, keywords ...string) int {
+	words := make([][]string, len(keywords))
+	for i, keyword := range keywords {
+		words[i] = strings.Fields(strings.ToUpper(keyword))
+	}
+	found := -1
+	scanTopLevel(statement, func(index int) {
+		if found >= 0 || index < from {
+			return
+		}
+		for _, item := range words {
+			if matchWords(statement, index, item) > 0 {
+				found = index
+				return
+			}
+		}
+	})
+	return found
+}
+
+// fromQualifier returns the alias, or the name, of the FROM item starting at index and the text of the item
+// when it is a subquery
+func fromQualifier(statement string, index int) (qualifier, subquery string) {
+	index = skipSpaces(statement, index)
+	name := ""
+	if index < len(statement) && statement[index] == '(' {
+		start, depth := index, 0
+		for ; index < len(statement); index++ {
+			if statement[index] == '(' {
+				depth++
+			} else if statement[index] == ')' {
+				if depth--; depth == 0 {
+					index++
+					break
+				}
+			}
+		}
+		subquery = strings.TrimSpace(statement[start+1 : index-1])
+	} else {
+		name, index = readIdentifier(statement, index)
+	}
+
+	index = skipSpaces(statement, index)
+	alias, next := readIdentifier(statement, index)
+	if strings.EqualFold(alias, "as") {
+		alias, _ = readIdentifier(statement, skipSpaces(statement, next))
+	}
+	if alias != "" && !softDeleteAliasStops[strings.ToLower(alias)] {
+		return alias, subquery
+	}
+	return name, subquery
+}
+
+// readIdentifier reads a possibly qualified and quoted identifier
+func readIdentifier(statement string, index int) (string, int) {
+	start := index
+	for index < len(statement) {
+		switch c := statement[index]; {
+		case c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(statement[index+1:], closing)
+			if end < 0 {
+				return "", len(statement)
+			}
+			index += end + 2
+		case isWordByte(c) || c == '.' || c == '$' || c == '#':
+			index++
+		default:
+			return statement[start:index], index
+		}
+	}
+	return statement[start:index], index
+}
+
+func skipSpaces(statement string, index int) int {
+	for index < len(statement) && strings.IndexByte(" \t\r\n", statement[index]) >= 0 {
+		index++
+	}
+	return index
+}
+
+// containsWord reports whether word appears in statement as a whole word, case insensitively
+func containsWord(statement, word string) bool {
+	lower, word := strings.ToLower(statement), strings.ToLower(word)
+	for offset := 0; ; {
+		index := strings.Index(lower[offset:], word)
+		if index < 0 {
+			return false
+		}
+		start, end := offset+index, offset+index+len(word)
+		if (start == 0 || !isWordByte(lower[start-1])) && (end == len(lower) || !isWordByte(lower[end])) {
+			return true
+		}
+		offset = start + 1
+	}
+}
@@ -0,0 +1,79 @@
+package db
+
+import "testing"
+
+func TestSoftDeleteFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+		want      string
+	}{
+		{
+			name:      "no where",
+			statement: "SELECT * FROM users",
+			want:      "SELECT * FROM users WHERE users.deleted_at IS NULL",
+		},
+		{
+			name:      "alias and order",
+			statement: "SELECT * FROM users u WHERE u.active = $1 ORDER BY u.id",
+			want:      "SELECT * FROM users u WHERE u.deleted_at IS NULL AND (u.active = $1) ORDER BY u.id",
+		},
+		{
+			name:      "as alias with join and or",
+			statement: "SELECT * FROM users AS u JOIN orders o ON o.user_id = u.id WHERE a = 1 OR b = 2 LIMIT 10",
+			want:      "SELECT * FROM users AS u JOIN orders o ON o.user_id = u.id WHERE u.deleted_at IS NULL AND (a = 1 OR b = 2) LIMIT 10",
+		},
+		{
+			name:      "subquery in select list",
+			statement: "SELECT id, (SELECT count(*) FROM orders WHERE orders.user_id = users.id) FROM users GROUP BY id",
+			want:      "SELECT id, (SELECT count(*) FROM orders WHERE orders.user_id = users.id) FROM users WHERE users.deleted_at IS NULL GROUP BY id",
+		},
+		{
+			name:      "keyword in literal",
+			statement: "select * from users where name = 'ORDER BY' order by id",
+			want:      "select * from users WHERE users.deleted_at IS NULL AND (name = 'ORDER BY') order by id",
+		},
+		{
+			name:      "aliased subquery selecting star",
+			statement: "SELECT * FROM (SELECT * FROM users) s",
+			want:      "SELECT * FROM (SELECT * FROM users) s WHERE s.deleted_at IS NULL",
+		},
+		{
+			name:      "aliased subquery selecting the column",
+			statement: "SELECT s.id FROM (SELECT u.id, u.deleted_at FROM users u) AS s ORDER BY s.id",
+			want:      "SELECT s.id FROM (SELECT u.id, u.deleted_at FROM users u) AS s WHERE s.deleted_at IS NULL ORDER BY s.id",
+		},
+		{
+			name:      "aliased subquery without the column",
+			statement: "SELECT * FROM (SELECT id FROM users) s",
+			want:      "SELECT * FROM (SELECT id FROM users) s",
+		},
+		{
+			name:      "subquery without alias",
+			statement: "SELECT * FROM (SELECT 1) WHERE x = 1",
+			want:      "SELECT * FROM (SELECT 1) WHERE x = 1",
+		},
+		{
+			name:      "column selected",
+			statement: "SELECT id, deleted_at FROM users ORDER BY deleted_at",
+			want:      "SELECT id, deleted_at FROM users WHERE users.deleted_at IS NULL ORDER BY deleted_at",
+		},
+		{
+			name:      "column already filtered",
+			statement: "SELECT * FROM users WHERE deleted_at IS NOT NULL",
+			want:      "SELECT * FROM users WHERE deleted_at IS NOT NULL",
+		},
+		{
+			name:      "no from",
+			statement: "SELECT 1",
+			want:      "SELECT 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SoftDeleteFilter(tt.statement, "deleted_at"); got != tt.want {
+				t.Errorf("SoftDeleteFilter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,81 +1,41 @@
 package db
 
 import (
-	"database/sql"
-	"fmt"
-	"strings"
+	"context"
 
 	"go.uber.org/zap"
 )
 
 type sqlServerDBHelper struct {
-	db *sql.DB
+	*baseDBHelper
 }
 
 // NewSQLServerDBHelper creates an instance
-func NewSQLServerDBHelper(host string, port int, username, password, database string) DBHelper {
-	dbConnection, err := initSQLServer(host, port, username, password, database)
+func NewSQLServerDBHelper(host string, port int, username, password, database string, opts ...DBOption) DBHelper {
+	return NewSQLServerDBHelperWithConfig(DBConfig{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		Database: database,
+	}, opts...)
+}
+
+// NewSQLServerDBHelperWithConfig creates an instance from config
+func NewSQLServerDBHelperWithConfig(cfg DBConfig, opts ...DBOption) DBHelper {
+	helper, err := OpenSQLServerDBHelper(context.Background(), cfg, opts...)
 	if err != nil {
 		zap.S().Panic("Failed to init SQL Server", zap.Error(err))
 	}
-	return &sqlServerDBHelper{
-		db: dbConnection,
-	}
-}
-
-func (h *sqlServerDBHelper) Open() *sql.DB {
-	return h.db
-}
-
-func (h *sqlServerDBHelper) Close() error {
-	return h.db.Close()
-}
-
-func (h *sqlServerDBHelper) Begin() (*sql.Tx, error) {
-	return h.db.Begin()
-}
-
-func (h *sqlServerDBHelper) Commit(tx *sql.Tx) error {
-	return tx.Commit()
+	return helper
 }
 
-func (h *sqlServerDBHelper) Rollback(tx *sql.Tx) error {
-	return tx.Rollback()
-}
-
-func (h *sqlServerDBHelper) QueryRowPaging(statement string, offset, limit uint32,
-	agruments []interface{}) (row *sql.Row) {
-	db := h.Open()
-	queryBuilder := strings.Builder{}
-	queryBuilder.WriteString(statement)
-	queryBuilder.WriteString(fmt.Sprintf(" offset :%d rows fetch next :%d rows only", len(agruments)+1, len(agruments)+2))
-	agruments = append(agruments, offset, limit)
-	return db.QueryRow(queryBuilder.String(), agruments...)
-}
-
-func (h *sqlServerDBHelper) QueryRowsPaging(statement string, offset, limit uint32,
-	agruments []interface{}) (rows *sql.Rows, errQuery error) {
-	db := h.Open()
-	queryBuilder := strings.Builder{}
-	queryBuilder.WriteString(statement)
-	queryBuilder.WriteString(fmt.Sprintf(" offset :%d rows fetch next :%d rows only", len(agruments)+1, len(agruments)+2))
-	agruments = append(agruments, offset, limit)
-	rows, errQuery = db.Query(queryBuilder.String(), agruments...)
-	if errQuery != nil {
-		return nil, errQuery
-	}
-	return rows, nil
-}
-
-func initSQLServer(host string, port int, username, password, database string) (*sql.DB, error) {
-	dbConnectionString := fmt.Sprintf("server=%s;port=%d;user id=%s;password=%s;database=%s;",
-		host, port, username, password, database)
-	dbConnection, err := sql.Open("sqlserver", dbConnectionString)
+// OpenSQLServerDBHelper creates an instance from config, the connection is retried according to the
+// connect_retry option within ctx, with lazy_connect it is established in the background
+func OpenSQLServerDBHelper(ctx context.Context, cfg DBConfig, opts ...DBOption) (DBHelper, error) {
+	base, err := openBaseDBHelper(ctx, "sqlServerDBHelper", "sqlserver", sqlServerDSN, SQLServerDialect, cfg, opts)
 	if err != nil {
 		return nil, err
 	}
-	if err := dbConnection.Ping(); err != nil {
-		return nil, err
-	}
-	return dbConnection, nil
+	return &sqlServerDBHelper{baseDBHelper: base}, nil
 }
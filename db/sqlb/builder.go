@@ -0,0 +1,102 @@
+// Package sqlb builds SELECT, INSERT, UPDATE, DELETE and UPSERT statements with bound parameters
+// rendered for a db.Dialect. Table and column names are written as given and must come from code,
+// never from user input; values always travel as arguments.
+package sqlb
+
+import (
+	"strings"
+
+	"go-core/db"
+)
+
+// Builder is implemented by the SELECT and INSERT builders, the UPDATE and DELETE builders also
+// return ErrNoConditions from Build
+type Builder interface {
+	Build(dialect db.Dialect) (string, []interface{})
+}
+
+// writer accumulates SQL and numbers the placeholders for a dialect
+type writer struct {
+	dialect   db.Dialect
+	sql       strings.Builder
+	arguments []interface{}
+}
+
+func newWriter(dialect db.Dialect) *writer {
+	return &writer{dialect: dialect}
+}
+
+func (w *writer) write(parts ...string) {
+	for _, part := range parts {
+		w.sql.WriteString(part)
+	}
+}
+
+// bind writes a placeholder for value
+func (w *writer) bind(value interface{}) {
+	w.arguments = append(w.arguments, value)
+	w.sql.WriteString(w.dialect.Placeholder(len(w.arguments)))
+}
+
+// bindList writes comma separated placeholders
+func (w *writer) bindList(values []interface{}) {
+	for i, value := range values {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.bind(value)
+	}
+}
+
+// expr writes fragment replacing every ? outside of quotes with the next argument, ?? writes a literal ?
+func (w *writer) expr(fragment string, arguments []interface{}) {
+	next := 0
+	var quote byte
+	for i := 0; i < len(fragment); i++ {
+		c := fragment[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?' && i+1 < len(fragment) && fragment[i+1] == '?':
+			i++
+		case c == '?' && next < len(arguments):
+			w.bind(arguments[next])
+			next++
+			continue
+		}
+		w.sql.WriteByte(c)
+	}
+}
+
+func (w *writer) result() (string, []interface{}) {
+	return w.sql.String(), w.arguments
+}
+
+// where writes the non empty conditions joined with AND
+func (w *writer) where(conditions []Cond) {
+	conditions = nonEmpty(conditions)
+	for i, condition := range conditions {
+		if i == 0 {
+			w.write(" WHERE ")
+		} else {
+			w.write(" AND ")
+		}
+		w.operand(condition, len(conditions) > 1)
+	}
+}
+
+// operand renders a condition joined to others, raw expressions are parenthesized
+// so that an OR inside them does not bind looser than the joining operator
+func (w *writer) operand(condition Cond, joined bool) {
+	if _, isExpr := condition.(exprCond); isExpr && joined {
+		w.write("(")
+		condition.render(w)
+		w.write(")")
+		return
+	}
+	condition.render(w)
+}
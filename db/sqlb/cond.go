@@ -0,0 +1,215 @@
+package sqlb
+
+type (
+	// Cond is a boolean SQL expression with its arguments
+	Cond interface {
+		render(w *writer)
+	}
+
+	exprCond struct {
+		fragment  string
+		arguments []interface{}
+	}
+
+	compareCond struct {
+		column   string
+		operator string
+		value    interface{}
+	}
+
+	nullCond struct {
+		column string
+		not    bool
+	}
+
+	inCond struct {
+		column string
+		values []interface{}
+		not    bool
+	}
+
+	listCond struct {
+		operator   string
+		conditions []Cond
+	}
+
+	notCond struct {
+		condition Cond
+	}
+)
+
+// Expr is a raw condition, every ? is replaced by the next argument
+func Expr(fragment string, arguments ...interface{}) Cond {
+	return exprCond{fragment: fragment, arguments: arguments}
+}
+
+// Eq renders column = value, or column IS NULL when value is nil
+func Eq(column string, value interface{}) Cond {
+	if value == nil {
+		return IsNull(column)
+	}
+	return compareCond{column: column, operator: "=", value: value}
+}
+
+// NotEq renders column <> value, or column IS NOT NULL when value is nil
+func NotEq(column string, value interface{}) Cond {
+	if value == nil {
+		return IsNotNull(column)
+	}
+	return compareCond{column: column, operator: "<>", value: value}
+}
+
+// Lt renders column < value
+func Lt(column string, value interface{}) Cond {
+	return compareCond{column: column, operator: "<", value: value}
+}
+
+// Lte renders column <= value
+func Lte(column string, value interface{}) Cond {
+	return compareCond{column: column, operator: "<=", value: value}
+}
+
+// Gt renders column > value
+func Gt(column string, value interface{}) Cond {
+	return compareCond{column: column, operator: ">", value: value}
+}
+
+// Gte renders column >= value
+func Gte(column string, value interface{}) Cond {
+	return compareCond{column: column, operator: ">=", value: value}
+}
+
+// Like renders column LIKE pattern
+func Like(column string, pattern interface{}) Cond {
+	return compareCond{column: column, operator: "LIKE", value: pattern}
+}
+
+// IsNull renders column IS NULL
+func IsNull(column string) Cond {
+	return nullCond{column: column}
+}
+
+// IsNotNull renders column IS NOT NULL
+func IsNotNull(column string) Cond {
+	return nullCond{column: column, not: true}
+}
+
+// In renders column IN (...), an empty list matches nothing
+func In(column string, values ...interface{}) Cond {
+	return inCond{column: column, values: values}
+}
+
+// NotIn renders column NOT IN (...), an empty list matches everything
+func NotIn(column string, values ...interface{}) Cond {
+	return inCond{column: column, values: values, not: true}
+}
+
+// And joins the non empty conditions with AND
+func And(conditions ...Cond) Cond {
+	return listCond{operator: " AND ", conditions: conditions}
+}
+
+// Or joins the non empty conditions with OR
+func Or(conditions ...Cond) Cond {
+	return listCond{operator: " OR ", conditions: conditions}
+}
+
+// Not negates condition
+func Not(condition Cond) Cond {
+	return notCond{condition: condition}
+}
+
+// If returns condition when ok, otherwise an empty condition skipped by And, Or and Where
+func If(ok bool, condition Cond) Cond {
+	if !ok {
+		return nil
+	}
+	return condition
+}
+
+func isEmpty(condition Cond) bool {
+	if condition == nil {
+		return true
+	}
+	if list, ok := condition.(listCond); ok {
+		for _, item := range list.conditions {
+			if !isEmpty(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// nonEmpty drops the conditions skipped by If and the empty lists
+func nonEmpty(conditions []Cond) []Cond {
+	var result []Cond
+	for _, item := range conditions {
+		if !isEmpty(item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func (c exprCond) render(w *writer) {
+	w.expr(c.fragment, c.arguments)
+}
+
+func (c compareCond) render(w *writer) {
+	w.write(c.column, " ", c.operator, " ")
+	w.bind(c.value)
+}
+
+func (c nullCond) render(w *writer) {
+	if c.not {
+		w.write(c.column, " IS NOT NULL")
+	} else {
+		w.write(c.column, " IS NULL")
+	}
+}
+
+func (c inCond) render(w *writer) {
+	if len(c.values) == 0 {
+		if c.not {
+			w.write("1 = 1")
+		} else {
+			w.write("1 = 0")
+		}
+		return
+	}
+	w.write(c.column)
+	if c.not {
+		w.write(" NOT")
+	}
+	w.write(" IN (")
+	w.bindList(c.values)
+	w.write(")")
+}
+
+func (c listCond) render(w *writer) {
+	conditions := nonEmpty(c.conditions)
+	if len(conditions) == 1 {
+		conditions[0].render(w)
+		return
+	}
+	w.write("(")
+	for i, item := range conditions {
+		if i > 0 {
+			w.write(c.operator)
+		}
+		w.operand(item, true)
+	}
+	w.write(")")
+}
+
+func (c notCond) render(w *writer) {
+	if isEmpty(c.condition) {
+		w.write("1 = 1")
+		return
+	}
+	w.write("NOT (")
+	c.condition.render(w)
+	w.write(")")
+}
@@ -0,0 +1,207 @@
+package sqlb
+
+import (
+	"sort"
+	"strings"
+
+	"go-core/db"
+)
+
+// InsertBuilder builds an INSERT statement, or an upsert when conflict columns are set
+type InsertBuilder struct {
+	table     string
+	columns   []string
+	rows      [][]interface{}
+	conflict  []string
+	update    []string
+	hasUpdate bool
+}
+
+// Insert starts an INSERT into table
+func Insert(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+// Upsert starts an INSERT into table that updates the row matching keyColumns instead of failing.
+// Postgres and SQLite render ON CONFLICT, MySQL ON DUPLICATE KEY UPDATE, Oracle and SQL Server MERGE.
+func Upsert(table string, keyColumns ...string) *InsertBuilder {
+	return &InsertBuilder{table: table, conflict: keyColumns}
+}
+
+// Columns sets the inserted columns
+func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	b.columns = columns
+	return b
+}
+
+// Values appends a row, values are in Columns order
+func (b *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	b.rows = append(b.rows, values)
+	return b
+}
+
+// SetMap sets the columns from the sorted keys of values and appends a single row
+func (b *InsertBuilder) SetMap(values map[string]interface{}) *InsertBuilder {
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	row := make([]interface{}, len(columns))
+	for i, column := range columns {
+		row[i] = values[column]
+	}
+	b.columns = columns
+	b.rows = append(b.rows, row)
+	return b
+}
+
+// Update sets the columns overwritten on conflict, by default every non key column.
+// Calling it without columns leaves a conflicting row unchanged.
+func (b *InsertBuilder) Update(columns ...string) *InsertBuilder {
+	b.update = columns
+	b.hasUpdate = true
+	return b
+}
+
+// Build renders the statement and its arguments for dialect
+func (b *InsertBuilder) Build(dialect db.Dialect) (string, []interface{}) {
+	w := newWriter(dialect)
+	switch {
+	case len(b.conflict) == 0 && dialect.Name() == "oracle" && len(b.rows) > 1:
+		b.insertAll(w)
+	case len(b.conflict) == 0:
+		b.insert(w)
+	case dialect.Name() == "oracle" || dialect.Name() == "sqlserver":
+		b.merge(w)
+	case dialect.Name() == "mysql":
+		b.insert(w)
+		b.onDuplicateKey(w)
+	default:
+		b.insert(w)
+		b.onConflict(w)
+	}
+	return w.result()
+}
+
+func (b *InsertBuilder) updateColumns() []string {
+	if b.hasUpdate {
+		return b.update
+	}
+	keys := make(map[string]bool, len(b.conflict))
+	for _, column := range b.conflict {
+		keys[column] = true
+	}
+	var columns []string
+	for _, column := range b.columns {
+		if !keys[column] {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+func (b *InsertBuilder) insert(w *writer) {
+	w.write("INSERT INTO ", b.table, " (", strings.Join(b.columns, ", "), ") VALUES ")
+	for i, row := range b.rows {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write("(")
+		w.bindList(row)
+		w.write(")")
+	}
+}
+
+// insertAll renders a multi row insert for Oracle which has no multi row VALUES
+func (b *InsertBuilder) insertAll(w *writer) {
+	w.write("INSERT ALL")
+	for _, row := range b.rows {
+		w.write(" INTO ", b.table, " (", strings.Join(b.columns, ", "), ") VALUES (")
+		w.bindList(row)
+		w.write(")")
+	}
+	w.write(" SELECT 1 FROM DUAL")
+}
+
+func (b *InsertBuilder) onConflict(w *writer) {
+	w.write(" ON CONFLICT (", strings.Join(b.conflict, ", "), ")")
+	columns := b.updateColumns()
+	if len(columns) == 0 {
+		w.write(" DO NOTHING")
+		return
+	}
+	w.write(" DO UPDATE SET ")
+	for i, column := range columns {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write(column, " = EXCLUDED.", column)
+	}
+}
+
+func (b *InsertBuilder) onDuplicateKey(w *writer) {
+	columns := b.updateColumns()
+	if len(columns) == 0 {
+		// MySQL has no DO NOTHING, assigning a key to itself is the idiomatic no-op
+		columns = b.conflict[:1]
+	}
+	w.write(" ON DUPLICATE KEY UPDATE ")
+	for i, column := range columns {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write(column, " = VALUES(", column, ")")
+	}
+}
+
+func (b *InsertBuilder) merge(w *writer) {
+	oracle := w.dialect.Name() == "oracle"
+	w.write("MERGE INTO ", b.table)
+	if !oracle {
+		w.write(" WITH (HOLDLOCK)")
+	}
+	w.write(" target USING (")
+	for i, row := range b.rows {
+		if i > 0 {
+			w.write(" UNION ALL ")
+		}
+		w.write("SELECT ")
+		for j, value := range row {
+			if j > 0 {
+				w.write(", ")
+			}
+			w.bind(value)
+			w.write(" AS ", b.columns[j])
+		}
+		if oracle {
+			w.write(" FROM DUAL")
+		}
+	}
+	w.write(") source ON (")
+	for i, column := range b.conflict {
+		if i > 0 {
+			w.write(" AND ")
+		}
+		w.write("target.", column, " = source.", column)
+	}
+	w.write(")")
+	if columns := b.updateColumns(); len(columns) > 0 {
+		w.write(" WHEN MATCHED THEN UPDATE SET ")
+		for i, column := range columns {
+			if i > 0 {
+				w.write(", ")
+			}
+			w.write("target.", column, " = source.", column)
+		}
+	}
+	sources := make([]string, len(b.columns))
+	for i, column := range b.columns {
+		sources[i] = "source." + column
+	}
+	w.write(" WHEN NOT MATCHED THEN INSERT (", strings.Join(b.columns, ", "), ") VALUES (", strings.Join(sources, ", "), ")")
+	if !oracle {
+		// SQL Server requires MERGE to be terminated
+		w.write(";")
+	}
+}
@@ -0,0 +1,103 @@
+package sqlb
+
+import (
+	"reflect"
+	"testing"
+
+	"go-core/db"
+)
+
+func TestInsertBuild(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   *InsertBuilder
+		dialect db.Dialect
+		want    string
+	}{
+		{
+			name:    "postgres rows",
+			query:   Insert("users").Columns("id", "name").Values(1, "a").Values(2, "b"),
+			dialect: db.PostgresDialect,
+			want:    "INSERT INTO users (id, name) VALUES ($1, $2), ($3, $4)",
+		},
+		{
+			name:    "sqlserver rows",
+			query:   Insert("users").Columns("id", "name").Values(1, "a").Values(2, "b"),
+			dialect: db.SQLServerDialect,
+			want:    "INSERT INTO users (id, name) VALUES (@p1, @p2), (@p3, @p4)",
+		},
+		{
+			name:    "oracle rows",
+			query:   Insert("users").Columns("id", "name").Values(1, "a").Values(2, "b"),
+			dialect: db.OracleDialect,
+			want:    "INSERT ALL INTO users (id, name) VALUES (:1, :2) INTO users (id, name) VALUES (:3, :4) SELECT 1 FROM DUAL",
+		},
+		{
+			name:    "postgres upsert",
+			query:   Upsert("users", "id").Columns("id", "name").Values(1, "a").Values(2, "b"),
+			dialect: db.PostgresDialect,
+			want:    "INSERT INTO users (id, name) VALUES ($1, $2), ($3, $4) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name",
+		},
+		{
+			name:    "sqlite upsert",
+			query:   Upsert("users", "id").Columns("id", "name").Values(1, "a").Values(2, "b"),
+			dialect: db.SQLiteDialect,
+			want:    "INSERT INTO users (id, name) VALUES (?, ?), (?, ?) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name",
+		},
+		{
+			name:    "mysql upsert",
+			query:   Upsert("users", "id").Columns("id", "name").Values(1, "a").Values(2, "b"),
+			dialect: db.MySQLDialect,
+			want:    "INSERT INTO users (id, name) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name)",
+		},
+		{
+			name:    "oracle upsert",
+			query:   Upsert("users", "id").Columns("id", "name").Values(1, "a").Values(2, "b"),
+			dialect: db.OracleDialect,
+			want: "MERGE INTO users target USING (SELECT :1 AS id, :2 AS name FROM DUAL UNION ALL SELECT :3 AS id, :4 AS name FROM DUAL) " +
+				"source ON (target.id = source.id) WHEN MATCHED THEN UPDATE SET target.name = source.name " +
+				"WHEN NOT MATCHED THEN INSERT (id, name) VALUES (source.id, source.name)",
+		},
+		{
+			name:    "sqlserver upsert",
+			query:   Upsert("users", "id").Columns("id", "name").Values(1, "a").Values(2, "b"),
+			dialect: db.SQLServerDialect,
+			want: "MERGE INTO users WITH (HOLDLOCK) target USING (SELECT @p1 AS id, @p2 AS name UNION ALL SELECT @p3 AS id, @p4 AS name) " +
+				"source ON (target.id = source.id) WHEN MATCHED THEN UPDATE SET target.name = source.name " +
+				"WHEN NOT MATCHED THEN INSERT (id, name) VALUES (source.id, source.name);",
+		},
+		{
+			name:    "postgres upsert without update",
+			query:   Upsert("users", "id").Columns("id", "name").Values(1, "a").Values(2, "b").Update(),
+			dialect: db.PostgresDialect,
+			want:    "INSERT INTO users (id, name) VALUES ($1, $2), ($3, $4) ON CONFLICT (id) DO NOTHING",
+		},
+		{
+			name:    "mysql upsert without update",
+			query:   Upsert("users", "id").Columns("id", "name").Values(1, "a").Values(2, "b").Update(),
+			dialect: db.MySQLDialect,
+			want:    "INSERT INTO users (id, name) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE id = VALUES(id)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args := tt.query.Build(tt.dialect)
+			if got != tt.want {
+				t.Errorf("Build() = %q, want %q", got, tt.want)
+			}
+			if want := []interface{}{1, "a", 2, "b"}; !reflect.DeepEqual(args, want) {
+				t.Errorf("Build() args = %#v, want %#v", args, want)
+			}
+		})
+	}
+}
+
+func TestInsertSetMap(t *testing.T) {
+	got, args := Insert("users").SetMap(map[string]interface{}{"b": 2, "a": 1}).Build(db.PostgresDialect)
+	if want := "INSERT INTO users (a, b) VALUES ($1, $2)"; got != want {
+		t.Errorf("Build() = %q, want %q", got, want)
+	}
+	if want := []interface{}{1, 2}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Build() args = %#v, want %#v", args, want)
+	}
+}
@@ -0,0 +1,115 @@
+package sqlb
+
+import (
+	"strings"
+
+	"go-core/db"
+)
+
+// SelectBuilder builds a SELECT statement
+type SelectBuilder struct {
+	columns  []string
+	from     string
+	joins    []exprCond
+	where    []Cond
+	groupBy  []string
+	having   []Cond
+	orderBy  []string
+	limit    uint32
+	offset   uint32
+	hasLimit bool
+}
+
+// Select starts a SELECT of columns, no columns selects *
+func Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{columns: columns}
+}
+
+// From sets the table or subquery text
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.from = table
+	return b
+}
+
+// Join appends a join clause, e.g. Join("LEFT JOIN orders o ON o.user_id = u.id AND o.status = ?", status)
+func (b *SelectBuilder) Join(clause string, arguments ...interface{}) *SelectBuilder {
+	b.joins = append(b.joins, exprCond{fragment: clause, arguments: arguments})
+	return b
+}
+
+// Where appends conditions joined with AND, nil conditions are skipped
+func (b *SelectBuilder) Where(conditions ...Cond) *SelectBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+// WhereIf appends condition only when ok
+func (b *SelectBuilder) WhereIf(ok bool, condition Cond) *SelectBuilder {
+	return b.Where(If(ok, condition))
+}
+
+// GroupBy appends grouping columns
+func (b *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, columns...)
+	return b
+}
+
+// Having appends HAVING conditions joined with AND
+func (b *SelectBuilder) Having(conditions ...Cond) *SelectBuilder {
+	b.having = append(b.having, conditions...)
+	return b
+}
+
+// OrderBy appends ordering terms, e.g. OrderBy("created_at DESC", "id")
+func (b *SelectBuilder) OrderBy(terms ...string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, terms...)
+	return b
+}
+
+// Limit sets the maximum number of rows
+func (b *SelectBuilder) Limit(limit uint32) *SelectBuilder {
+	b.limit = limit
+	b.hasLimit = true
+	return b
+}
+
+// Offset sets the number of rows to skip, it needs a Limit
+func (b *SelectBuilder) Offset(offset uint32) *SelectBuilder {
+	b.offset = offset
+	return b
+}
+
+// Build renders the statement and its arguments for dialect
+func (b *SelectBuilder) Build(dialect db.Dialect) (string, []interface{}) {
+	w := newWriter(dialect)
+	w.write("SELECT ")
+	if len(b.columns) == 0 {
+		w.write("*")
+	} else {
+		w.write(strings.Join(b.columns, ", "))
+	}
+	w.write(" FROM ", b.from)
+	for _, join := range b.joins {
+		w.write(" ")
+		join.render(w)
+	}
+	w.where(b.where)
+	if len(b.groupBy) > 0 {
+		w.write(" GROUP BY ", strings.Join(b.groupBy, ", "))
+	}
+	if having := And(b.having...); !isEmpty(having) {
+		w.write(" HAVING ")
+		having.render(w)
+	}
+	if len(b.orderBy) > 0 {
+		w.write(" ORDER BY ", strings.Join(b.orderBy, ", "))
+	}
+	statement, arguments := w.result()
+	switch {
+	case b.hasLimit && b.offset > 0:
+		return dialect.Paging(statement, b.offset, b.limit, arguments)
+	case b.hasLimit:
+		return dialect.Limit(statement, b.limit, arguments)
+	}
+	return statement, arguments
+}
@@ -0,0 +1,137 @@
+package sqlb
+
+import (
+	"reflect"
+	"testing"
+
+	"go-core/db"
+)
+
+func TestSelectBuild(t *testing.T) {
+	query := Select("u.id", "u.name").From("users u").
+		Join("LEFT JOIN orders o ON o.user_id = u.id AND o.status = ?", "paid").
+		Where(Eq("u.active", true), In("u.role", "a", "b"), Eq("u.deleted_at", nil), If(false, Eq("x", 1)),
+			Or(Like("u.name", "a%"), Gt("u.age", 3))).
+		GroupBy("u.id", "u.name").
+		Having(Expr("COUNT(o.id) > ?", 1)).
+		OrderBy("u.id").
+		Limit(10).
+		Offset(20)
+	const prefix = "SELECT u.id, u.name FROM users u LEFT JOIN orders o ON o.user_id = u.id AND o.status = "
+	tests := []struct {
+		name     string
+		dialect  db.Dialect
+		want     string
+		wantArgs []interface{}
+	}{
+		{
+			name:    "postgres",
+			dialect: db.PostgresDialect,
+			want: prefix + "$1 WHERE u.active = $2 AND u.role IN ($3, $4) AND u.deleted_at IS NULL AND " +
+				"(u.name LIKE $5 OR u.age > $6) GROUP BY u.id, u.name HAVING COUNT(o.id) > $7 ORDER BY u.id OFFSET $8 LIMIT $9",
+			wantArgs: []interface{}{"paid", true, "a", "b", "a%", 3, 1, uint32(20), uint32(10)},
+		},
+		{
+			name:    "oracle",
+			dialect: db.OracleDialect,
+			want: prefix + ":1 WHERE u.active = :2 AND u.role IN (:3, :4) AND u.deleted_at IS NULL AND " +
+				"(u.name LIKE :5 OR u.age > :6) GROUP BY u.id, u.name HAVING COUNT(o.id) > :7 ORDER BY u.id " +
+				"OFFSET :8 ROWS FETCH NEXT :9 ROWS ONLY",
+			wantArgs: []interface{}{"paid", true, "a", "b", "a%", 3, 1, uint32(20), uint32(10)},
+		},
+		{
+			name:    "sqlserver",
+			dialect: db.SQLServerDialect,
+			want: prefix + "@p1 WHERE u.active = @p2 AND u.role IN (@p3, @p4) AND u.deleted_at IS NULL AND " +
+				"(u.name LIKE @p5 OR u.age > @p6) GROUP BY u.id, u.name HAVING COUNT(o.id) > @p7 ORDER BY u.id " +
+				"OFFSET @p8 ROWS FETCH NEXT @p9 ROWS ONLY",
+			wantArgs: []interface{}{"paid", true, "a", "b", "a%", 3, 1, uint32(20), uint32(10)},
+		},
+		{
+			name:    "mysql",
+			dialect: db.MySQLDialect,
+			want: prefix + "? WHERE u.active = ? AND u.role IN (?, ?) AND u.deleted_at IS NULL AND " +
+				"(u.name LIKE ? OR u.age > ?) GROUP BY u.id, u.name HAVING COUNT(o.id) > ? ORDER BY u.id LIMIT ? OFFSET ?",
+			wantArgs: []interface{}{"paid", true, "a", "b", "a%", 3, 1, uint32(10), uint32(20)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args := query.Build(tt.dialect)
+			if got != tt.want {
+				t.Errorf("Build() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Build() args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestSelectConditions(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    *SelectBuilder
+		want     string
+		wantArgs []interface{}
+	}{
+		{
+			name:  "no condition",
+			query: Select().From("t"),
+			want:  "SELECT * FROM t",
+		},
+		{
+			name:     "operators",
+			query:    Select().From("t").Where(NotEq("a", nil), NotEq("b", 1), Lt("c", 1), Gte("d", 2), IsNotNull("e")),
+			want:     "SELECT * FROM t WHERE a IS NOT NULL AND b <> $1 AND c < $2 AND d >= $3 AND e IS NOT NULL",
+			wantArgs: []interface{}{1, 1, 2},
+		},
+		{
+			name:  "empty lists",
+			query: Select().From("t").Where(And(), Or(), NotIn("f")),
+			want:  "SELECT * FROM t WHERE 1 = 1",
+		},
+		{
+			name:  "empty in matches nothing",
+			query: Select().From("t").Where(In("f")),
+			want:  "SELECT * FROM t WHERE 1 = 0",
+		},
+		{
+			name:     "expression joined with AND",
+			query:    Select().From("t").Where(Expr("a = ? OR b = ?", 1, 2), Eq("tenant_id", 3)),
+			want:     "SELECT * FROM t WHERE (a = $1 OR b = $2) AND tenant_id = $3",
+			wantArgs: []interface{}{1, 2, 3},
+		},
+		{
+			name:     "expression in a list",
+			query:    Select().From("t").Where(Or(Expr("a = ? AND b = ?", 1, 2), Eq("c", 3)), Expr("d = ?", 4)),
+			want:     "SELECT * FROM t WHERE ((a = $1 AND b = $2) OR c = $3) AND (d = $4)",
+			wantArgs: []interface{}{1, 2, 3, 4},
+		},
+		{
+			name:     "single expression",
+			query:    Select().From("t").Where(Expr("a = ? OR b = ?", 1, 2), If(false, Eq("c", 3))),
+			want:     "SELECT * FROM t WHERE a = $1 OR b = $2",
+			wantArgs: []interface{}{1, 2},
+		},
+		{
+			name:     "negation and limit",
+			query:    Select("id").From("t").Where(Not(Lte("age", 3))).WhereIf(true, Eq("b", "x")).Limit(5),
+			want:     "SELECT id FROM t WHERE NOT (age <= $1) AND b = $2 LIMIT $3",
+			wantArgs: []interface{}{3, "x", uint32(5)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args := tt.query.Build(db.PostgresDialect)
+			if got != tt.want {
+				t.Errorf("Build() = %q, want %q", got, tt.want)
+			}
+			if len(args) != 0 || len(tt.wantArgs) != 0 {
+				if !reflect.DeepEqual(args, tt.wantArgs) {
+					t.Errorf("Build() args = %#v, want %#v", args, tt.wantArgs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package sqlb
+
+import (
+	"errors"
+	"sort"
+
+	"go-core/db"
+)
+
+// ErrNoConditions is returned by the UPDATE and DELETE builders whose conditions were all skipped,
+// All opts in to affecting every row
+var ErrNoConditions = errors.New("sqlb: UPDATE or DELETE without conditions, call All to affect every row")
+
+type assignment struct {
+	column string
+	value  interface{}
+	expr   *exprCond
+}
+
+// UpdateBuilder builds an UPDATE statement
+type UpdateBuilder struct {
+	table string
+	set   []assignment
+	where []Cond
+	all   bool
+}
+
+// Update starts an UPDATE of table
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// Set assigns value to column
+func (b *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	b.set = append(b.set, assignment{column: column, value: value})
+	return b
+}
+
+// SetIf assigns value to column only when ok
+func (b *UpdateBuilder) SetIf(ok bool, column string, value interface{}) *UpdateBuilder {
+	if ok {
+		return b.Set(column, value)
+	}
+	return b
+}
+
+// SetExpr assigns a raw expression to column, e.g. SetExpr("version", "version + ?", 1)
+func (b *UpdateBuilder) SetExpr(column, fragment string, arguments ...interface{}) *UpdateBuilder {
+	b.set = append(b.set, assignment{column: column, expr: &exprCond{fragment: fragment, arguments: arguments}})
+	return b
+}
+
+// SetMap assigns every value in values, in sorted column order
+func (b *UpdateBuilder) SetMap(values map[string]interface{}) *UpdateBuilder {
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		b.Set(column, values[column])
+	}
+	return b
+}
+
+// Where appends conditions joined with AND, nil conditions are skipped
+func (b *UpdateBuilder) Where(conditions ...Cond) *UpdateBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+// WhereIf appends condition only when ok
+func (b *UpdateBuilder) WhereIf(ok bool, condition Cond) *UpdateBuilder {
+	return b.Where(If(ok, condition))
+}
+
+// All allows Build without conditions, updating every row of the table
+func (b *UpdateBuilder) All() *UpdateBuilder {
+	b.all = true
+	return b
+}
+
+// Build renders the statement and its arguments for dialect, ErrNoConditions is returned
+// when every condition was skipped unless All was called
+func (b *UpdateBuilder) Build(dialect db.Dialect) (string, []interface{}, error) {
+	if !b.all && len(nonEmpty(b.where)) == 0 {
+		return "", nil, ErrNoConditions
+	}
+	w := newWriter(dialect)
+	w.write("UPDATE ", b.table, " SET ")
+	for i, set := range b.set {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write(set.column, " = ")
+		if set.expr != nil {
+			set.expr.render(w)
+		} else {
+			w.bind(set.value)
+		}
+	}
+	w.where(b.where)
+	statement, arguments := w.result()
+	return statement, arguments, nil
+}
+
+// DeleteBuilder builds a DELETE statement
+type DeleteBuilder struct {
+	table string
+	where []Cond
+	all   bool
+}
+
+// Delete starts a DELETE from table
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+// Where appends conditions joined with AND, nil conditions are skipped
+func (b *DeleteBuilder) Where(conditions ...Cond) *DeleteBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+// WhereIf appends condition only when ok
+func (b *DeleteBuilder) WhereIf(ok bool, condition Cond) *DeleteBuilder {
+	return b.Where(If(ok, condition))
+}
+
+// All allows Build without conditions, deleting every row of the table
+func (b *DeleteBuilder) All() *DeleteBuilder {
+	b.all = true
+	return b
+}
+
+// Build renders the statement and its arguments for dialect, ErrNoConditions is returned
+// when every condition was skipped unless All was called
+func (b *DeleteBuilder) Build(dialect db.Dialect) (string, []interface{}, error) {
+	if !b.all && len(nonEmpty(b.where)) == 0 {
+		return "", nil, ErrNoConditions
+	}
+	w := newWriter(dialect)
+	w.write("DELETE FROM ", b.table)
+	w.where(b.where)
+	statement, arguments := w.result()
+	return statement, arguments, nil
+}
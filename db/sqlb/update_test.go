@@ -0,0 +1,85 @@
+package sqlb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go-core/db"
+)
+
+func TestUpdateDeleteBuild(t *testing.T) {
+	type builder interface {
+		Build(dialect db.Dialect) (string, []interface{}, error)
+	}
+	tests := []struct {
+		name     string
+		query    builder
+		dialect  db.Dialect
+		want     string
+		wantArgs []interface{}
+		wantErr  error
+	}{
+		{
+			name: "update",
+			query: Update("users").Set("name", "a").SetIf(false, "x", 1).SetExpr("version", "version + ?", 1).
+				Where(Eq("id", 3), NotIn("role", "x")),
+			dialect:  db.SQLServerDialect,
+			want:     "UPDATE users SET name = @p1, version = version + @p2 WHERE id = @p3 AND role NOT IN (@p4)",
+			wantArgs: []interface{}{"a", 1, 3, "x"},
+		},
+		{
+			name:     "update map",
+			query:    Update("users").SetMap(map[string]interface{}{"b": 2, "a": 1}).Where(Eq("id", 3)),
+			dialect:  db.PostgresDialect,
+			want:     "UPDATE users SET a = $1, b = $2 WHERE id = $3",
+			wantArgs: []interface{}{1, 2, 3},
+		},
+		{
+			name:     "delete",
+			query:    Delete("users").Where(Not(Lte("age", 3))).WhereIf(false, Eq("id", 1)),
+			dialect:  db.OracleDialect,
+			want:     "DELETE FROM users WHERE NOT (age <= :1)",
+			wantArgs: []interface{}{3},
+		},
+		{
+			name:    "update without conditions",
+			query:   Update("users").Set("name", "a").WhereIf(false, Eq("id", 1)).Where(Or()),
+			dialect: db.PostgresDialect,
+			wantErr: ErrNoConditions,
+		},
+		{
+			name:     "update all",
+			query:    Update("users").Set("name", "a").WhereIf(false, Eq("id", 1)).All(),
+			dialect:  db.PostgresDialect,
+			want:     "UPDATE users SET name = $1",
+			wantArgs: []interface{}{"a"},
+		},
+		{
+			name:    "delete without conditions",
+			query:   Delete("users").Where(If(false, Eq("id", 1))),
+			dialect: db.MySQLDialect,
+			wantErr: ErrNoConditions,
+		},
+		{
+			name:    "delete all",
+			query:   Delete("users").All(),
+			dialect: db.MySQLDialect,
+			want:    "DELETE FROM users",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args, err := tt.query.Build(tt.dialect)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Build() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Build() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Build() args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+
+	log "go-core/log"
+
+	"go.uber.org/zap"
+)
+
+type sqliteDBHelper struct {
+	*baseDBHelper
+}
+
+// NewSQLiteDBHelper creates an instance on the database file at path, ":memory:" opens an in-memory database
+func NewSQLiteDBHelper(path string, opts ...DBOption) DBHelper {
+	return NewSQLiteDBHelperWithConfig(DBConfig{Database: path}, opts...)
+}
+
+// NewSQLiteDBHelperWithConfig creates an instance from config, Database is the file path and Params
+// are passed to the driver (e.g. _busy_timeout, _foreign_keys)
+func NewSQLiteDBHelperWithConfig(cfg DBConfig, opts ...DBOption) DBHelper {
+	helper, err := OpenSQLiteDBHelper(context.Background(), cfg, opts...)
+	if err != nil {
+		log.Logger.Panic("Failed to init sqlite", zap.Error(err))
+	}
+	return helper
+}
+
+// OpenSQLiteDBHelper creates an instance from config, the connection is retried according to the
+// connect_retry option within ctx, with lazy_connect it is established in the background
+func OpenSQLiteDBHelper(ctx context.Context, cfg DBConfig, opts ...DBOption) (DBHelper, error) {
+	// every connection to :memory: opens a distinct database, a single connection keeps one
+	if cfg.Database == ":memory:" && cfg.MaxOpenConns == 0 {
+		cfg.MaxOpenConns = 1
+	}
+	base, err := openBaseDBHelper(ctx, "sqliteDBHelper", "sqlite3", sqliteDSN, SQLiteDialect, cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteDBHelper{baseDBHelper: base}, nil
+}
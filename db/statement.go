@@ -0,0 +1,111 @@
+package db
+
+import (
+	"strings"
+	"unicode"
+)
+
+// topLevelKeyword returns the index of the last occurrence of keyword (e.g. "ORDER BY") in statement
+// outside of parentheses, quotes and comments, or -1. Words of the keyword may be separated by any whitespace.
+func topLevelKeyword(statement, keyword string) int {
+	words := strings.Fields(strings.ToUpper(keyword))
+	found := -1
+	scanTopLevel(statement, func(index int) {
+		if end := matchWords(statement, index, words); end > 0 {
+			found = index
+		}
+	})
+	return found
+}
+
+// scanTopLevel calls visit with the index of every word start at parenthesis depth 0
+func scanTopLevel(statement string, visit func(index int)) {
+	depth := 0
+	for i := 0; i < len(statement); i++ {
+		switch c := statement[i]; {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			for i++; i < len(statement); i++ {
+				if statement[i] == closing {
+					// doubled quotes escape themselves
+					if i+1 < len(statement) && statement[i+1] == closing && closing != ']' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case c == '-' && i+1 < len(statement) && statement[i+1] == '-':
+			for i < len(statement) && statement[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(statement) && statement[i+1] == '*':
+			if end := strings.Index(statement[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(statement)
+			}
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && isWordByte(c) && (i == 0 || !isWordByte(statement[i-1])):
+			visit(i)
+		}
+	}
+}
+
+// matchWords returns the end index when the words start at index, 0 otherwise
+func matchWords(statement string, index int, words []string) int {
+	position := index
+	for n, word := range words {
+		if n > 0 {
+			start := position
+			for position < len(statement) && unicode.IsSpace(rune(statement[position])) {
+				position++
+			}
+			if position == start {
+				return 0
+			}
+		}
+		end := position + len(word)
+		if end > len(statement) || !strings.EqualFold(statement[position:end], word) {
+			return 0
+		}
+		if end < len(statement) && isWordByte(statement[end]) {
+			return 0
+		}
+		position = end
+	}
+	return position
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// hasOrderBy reports whether statement is ordered at the top level
+func hasOrderBy(statement string) bool {
+	return topLevelKeyword(statement, "ORDER BY") >= 0
+}
+
+// readLockClauses make a SELECT lock rows or create a table, it has to run on the primary
+var readLockClauses = []string{"FOR UPDATE", "FOR NO KEY UPDATE", "FOR SHARE", "FOR KEY SHARE", "LOCK IN SHARE MODE", "INTO"}
+
+// isReadStatement reports whether statement is a plain SELECT, which a replica can serve: statements starting
+// with another keyword, e.g. INSERT ... RETURNING or WITH, and locking or SELECT INTO statements are not
+func isReadStatement(statement string) bool {
+	first := -1
+	scanTopLevel(statement, func(index int) {
+		if first < 0 {
+			first = index
+		}
+	})
+	if first < 0 || matchWords(statement, first, []string{"SELECT"}) == 0 {
+		return false
+	}
+	return firstTopLevelKeyword(statement, first, readLockClauses...) < 0
+}
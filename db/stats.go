@@ -0,0 +1,71 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	log "go-core/log"
+
+	"github.com/uber/jaeger-lib/metrics"
+)
+
+// poolMetrics mirrors sql.DBStats, cumulative fields are exported as counters
+type poolMetrics struct {
+	MaxOpenConnections metrics.Gauge `metric:"max_open_connections"`
+	OpenConnections    metrics.Gauge `metric:"open_connections"`
+	InUse              metrics.Gauge `metric:"in_use"`
+	Idle               metrics.Gauge `metric:"idle"`
+
+	WaitCount         metrics.Counter `metric:"wait_count"`
+	WaitDurationMs    metrics.Counter `metric:"wait_duration_ms"`
+	MaxIdleClosed     metrics.Counter `metric:"max_idle_closed"`
+	MaxIdleTimeClosed metrics.Counter `metric:"max_idle_time_closed"`
+	MaxLifetimeClosed metrics.Counter `metric:"max_lifetime_closed"`
+}
+
+// reportStats exports db.Stats() every interval until stop is closed
+func reportStats(db *sql.DB, dialect Dialect, cfg DBConfig, stop <-chan struct{}) {
+	factory := cfg.MetricsFactory
+	if factory == nil {
+		factory = metrics.NullFactory
+	}
+	factory = factory.Namespace(metrics.NSOptions{
+		Name: "db_pool",
+		Tags: map[string]string{"db_type": dialect.Name(), "db_instance": cfg.Database},
+	})
+	poolMetrics := &poolMetrics{}
+	metrics.MustInit(poolMetrics, factory, nil)
+
+	ticker := time.NewTicker(cfg.StatsInterval)
+	defer ticker.Stop()
+	var previous sql.DBStats
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		stats := db.Stats()
+		poolMetrics.MaxOpenConnections.Update(int64(stats.MaxOpenConnections))
+		poolMetrics.OpenConnections.Update(int64(stats.OpenConnections))
+		poolMetrics.InUse.Update(int64(stats.InUse))
+		poolMetrics.Idle.Update(int64(stats.Idle))
+		poolMetrics.WaitCount.Inc(stats.WaitCount - previous.WaitCount)
+		poolMetrics.WaitDurationMs.Inc((stats.WaitDuration - previous.WaitDuration).Milliseconds())
+		poolMetrics.MaxIdleClosed.Inc(stats.MaxIdleClosed - previous.MaxIdleClosed)
+		poolMetrics.MaxIdleTimeClosed.Inc(stats.MaxIdleTimeClosed - previous.MaxIdleTimeClosed)
+		poolMetrics.MaxLifetimeClosed.Inc(stats.MaxLifetimeClosed - previous.MaxLifetimeClosed)
+		previous = stats
+
+		log.Logger.Infow("DB pool stats",
+			"db.type", dialect.Name(),
+			"db.instance", cfg.Database,
+			"max_open", stats.MaxOpenConnections,
+			"open", stats.OpenConnections,
+			"in_use", stats.InUse,
+			"idle", stats.Idle,
+			"wait_count", stats.WaitCount,
+			"wait_duration", stats.WaitDuration.String(),
+		)
+	}
+}
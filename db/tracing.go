@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go-core/opentracing/jaeger"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+// startSpan starts a client span tagged with the connection and the redacted statement,
+// a noop span is returned when tracing is disabled
+func (h *baseDBHelper) startSpan(ctx context.Context, method, statement string) opentracing.Span {
+	if !h.tracing {
+		return opentracing.NoopTracer{}.StartSpan(method)
+	}
+	tags := []opentracing.Tag{
+		{Key: string(ext.DBType), Value: h.dialect.Name()},
+		{Key: string(ext.DBInstance), Value: h.config.Database},
+		{Key: string(ext.PeerAddress), Value: h.config.Address()},
+	}
+	if statement != "" {
+		tags = append(tags, opentracing.Tag{Key: string(ext.DBStatement), Value: normalizeStatement(statement)})
+	}
+	return jaeger.Start(ctx, ">helper."+h.name+"/"+method, ext.SpanKindRPCClient, tags...)
+}
+
+// finishSpan records the affected rows of result and the error flag
+func finishSpan(span opentracing.Span, result sql.Result, err error) {
+	if result != nil && err == nil {
+		if affected, errAffected := result.RowsAffected(); errAffected == nil {
+			span.SetTag("db.rows_affected", affected)
+		}
+	}
+	jaeger.Finish(span, err)
+}
+
+// finishRowsSpan records the number of rows read and the error flag
+func finishRowsSpan(span opentracing.Span, count int64, err error) {
+	span.SetTag("db.rows_returned", count)
+	jaeger.Finish(span, err)
+}
+
+// normalizeStatement replaces string and numeric literals with ? and collapses whitespace,
+// bind parameters ($1, :1, @p1, ?) are kept since they carry no value
+func normalizeStatement(statement string) string {
+	builder := strings.Builder{}
+	builder.Grow(len(statement))
+	space := false
+	for i := 0; i < len(statement); i++ {
+		c := statement[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = builder.Len() > 0
+			continue
+		case space:
+			builder.WriteByte(' ')
+			space = false
+		}
+		switch {
+		case c == '\'':
+			for i++; i < len(statement); i++ {
+				if statement[i] == '\'' {
+					if i+1 < len(statement) && statement[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			builder.WriteByte('?')
+		case c >= '0' && c <= '9' && (i == 0 || !isWordByte(statement[i-1]) && !strings.ContainsRune("$:@.", rune(statement[i-1]))):
+			for i+1 < len(statement) && (isWordByte(statement[i+1]) || statement[i+1] == '.') {
+				i++
+			}
+			builder.WriteByte('?')
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,34 @@
+package db
+
+import "testing"
+
+func TestNormalizeStatement(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+		want      string
+	}{
+		{
+			name:      "literals",
+			statement: "SELECT * FROM users WHERE id = 42 AND name = 'it''s'",
+			want:      "SELECT * FROM users WHERE id = ? AND name = ?",
+		},
+		{
+			name:      "placeholders and whitespace",
+			statement: "SELECT  *\n\tFROM t WHERE a = $1 AND b = :2 AND c = @p3 AND d = ? AND e = 1.5",
+			want:      "SELECT * FROM t WHERE a = $1 AND b = :2 AND c = @p3 AND d = ? AND e = ?",
+		},
+		{
+			name:      "digits in identifiers",
+			statement: "SELECT col1, t2.x FROM t2 WHERE price > -3",
+			want:      "SELECT col1, t2.x FROM t2 WHERE price > -?",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeStatement(tt.statement); got != tt.want {
+				t.Errorf("normalizeStatement() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
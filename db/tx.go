@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultTxMaxRetries   = 3
+	defaultTxRetryBackoff = 50 * time.Millisecond
+)
+
+type (
+	// TxFunc runs inside a transaction, returning an error rolls it back
+	TxFunc func(tx *sql.Tx) error
+
+	// TxContextFunc runs inside a transaction, ctx carries the transaction so nested
+	// WithTx/WithTxContext calls made with it use savepoints
+	TxContextFunc func(ctx context.Context, tx *sql.Tx) error
+
+	txContextKey struct{}
+
+	txContextValue struct {
+		tx    *sql.Tx
+		depth int
+	}
+)
+
+// TxFromContext returns the transaction started by WithTxContext
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	value, ok := ctx.Value(txContextKey{}).(*txContextValue)
+	if !ok {
+		return nil, false
+	}
+	return value.tx, true
+}
+
+// ContextWithTx returns a context carrying tx, WithTx calls made with it run in savepoints of tx
+func ContextWithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	depth := 0
+	if parent, ok := ctx.Value(txContextKey{}).(*txContextValue); ok && parent.tx == tx {
+		depth = parent.depth
+	}
+	return context.WithValue(ctx, txContextKey{}, &txContextValue{tx: tx, depth: depth})
+}
+
+func (h *baseDBHelper) WithTx(ctx context.Context, opts *sql.TxOptions, fn TxFunc) error {
+	return h.WithTxContext(ctx, opts, func(ctx context.Context, tx *sql.Tx) error {
+		return fn(tx)
+	})
+}
+
+// WithTxContext commits when fn succeeds and rolls back when it fails or panics (the panic is propagated).
+// Serialization failures and deadlocks restart the whole transaction up to tx_max_retries times.
+// When ctx already carries a transaction fn runs in a savepoint of it instead, without retry.
+func (h *baseDBHelper) WithTxContext(ctx context.Context, opts *sql.TxOptions, fn TxContextFunc) (err error) {
+	if parent, ok := ctx.Value(txContextKey{}).(*txContextValue); ok {
+		return h.withSavepoint(ctx, parent, fn)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = h.runTx(ctx, opts, fn)
+		if err == nil || attempt >= h.txMaxRetries || !h.dialect.IsRetryable(err) {
+			return err
+		}
+		// exponential backoff with full jitter
+		backoff := h.txRetryBackoff << uint(attempt)
+		if backoff > 0 {
+			backoff = time.Duration(rand.Int63n(int64(backoff)) + 1)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (h *baseDBHelper) runTx(ctx context.Context, opts *sql.TxOptions, fn TxContextFunc) (err error) {
+	tx, err := h.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			_ = h.rollback(ctx, tx)
+			panic(recovered)
+		}
+	}()
+
+	txCtx := context.WithValue(ctx, txContextKey{}, &txContextValue{tx: tx})
+	if err = fn(txCtx, tx); err != nil {
+		_ = h.rollback(ctx, tx)
+		return err
+	}
+	return h.commit(ctx, tx)
+}
+
+func (h *baseDBHelper) withSavepoint(ctx context.Context, parent *txContextValue, fn TxContextFunc) (err error) {
+	current := &txContextValue{tx: parent.tx, depth: parent.depth + 1}
+	name := fmt.Sprintf("sp_%d", current.depth)
+	if _, err = parent.tx.ExecContext(ctx, h.dialect.Savepoint(name)); err != nil {
+		return err
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			_, _ = parent.tx.ExecContext(ctx, h.dialect.RollbackToSavepoint(name))
+			panic(recovered)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txContextKey{}, current), parent.tx); err != nil {
+		if _, errRollback := parent.tx.ExecContext(ctx, h.dialect.RollbackToSavepoint(name)); errRollback != nil {
+			return fmt.Errorf("%w (rollback to savepoint %s: %v)", err, name, errRollback)
+		}
+		return err
+	}
+	if release := h.dialect.ReleaseSavepoint(name); release != "" {
+		_, err = parent.tx.ExecContext(ctx, release)
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-core/db"
+	"go-core/db/dbtest"
+
+	"github.com/lib/pq"
+)
+
+func newAccounts(t *testing.T) db.DBHelper {
+	t.Helper()
+	helper, _ := dbtest.NewSQLite(t)
+	if _, err := helper.ExecContext(context.Background(), "CREATE TABLE accounts (name TEXT PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+	return helper
+}
+
+func accountNames(t *testing.T, helper db.DBHelper) []string {
+	t.Helper()
+	names, err := db.SelectAll[string](context.Background(), helper, "SELECT name FROM accounts ORDER BY name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func insertAccount(ctx context.Context, tx *sql.Tx, name string) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO accounts (name) VALUES (?)", name)
+	return err
+}
+
+func TestWithTxContext(t *testing.T) {
+	errFailed := errors.New("failed")
+	tests := []struct {
+		name      string
+		fn        func(ctx context.Context, helper db.DBHelper, tx *sql.Tx) error
+		wantErr   error
+		wantNames []string
+	}{
+		{
+			name: "commit",
+			fn: func(ctx context.Context, helper db.DBHelper, tx *sql.Tx) error {
+				return insertAccount(ctx, tx, "a")
+			},
+			wantNames: []string{"a"},
+		},
+		{
+			name: "rollback on error",
+			fn: func(ctx context.Context, helper db.DBHelper, tx *sql.Tx) error {
+				if err := insertAccount(ctx, tx, "a"); err != nil {
+					return err
+				}
+				return errFailed
+			},
+			wantErr: errFailed,
+		},
+		{
+			name: "failed savepoint keeps the outer transaction",
+			fn: func(ctx context.Context, helper db.DBHelper, tx *sql.Tx) error {
+				if err := insertAccount(ctx, tx, "a"); err != nil {
+					return err
+				}
+				err := helper.WithTxContext(ctx, nil, func(ctx context.Context, tx *sql.Tx) error {
+					if err := insertAccount(ctx, tx, "b"); err != nil {
+						return err
+					}
+					return errFailed
+				})
+				if err != errFailed {
+					return err
+				}
+				return insertAccount(ctx, tx, "c")
+			},
+			wantNames: []string{"a", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helper := newAccounts(t)
+			err := helper.WithTxContext(context.Background(), nil, func(ctx context.Context, tx *sql.Tx) error {
+				return tt.fn(ctx, helper, tx)
+			})
+			if err != tt.wantErr {
+				t.Fatalf("WithTxContext() error = %v, want %v", err, tt.wantErr)
+			}
+			if names := accountNames(t, helper); !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("accounts = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestWithTxContextPanic(t *testing.T) {
+	helper := newAccounts(t)
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != "boom" {
+				t.Errorf("recovered = %v, want boom", recovered)
+			}
+		}()
+		_ = helper.WithTxContext(context.Background(), nil, func(ctx context.Context, tx *sql.Tx) error {
+			if err := insertAccount(ctx, tx, "a"); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	}()
+	if names := accountNames(t, helper); len(names) != 0 {
+		t.Errorf("accounts = %v, want none", names)
+	}
+}
+
+func TestWithTxRetries(t *testing.T) {
+	helper, fake := dbtest.NewFake(t, db.PostgresDialect, db.DBOption{Key: "tx_retry_backoff", Value: time.Millisecond})
+	serialization := &pq.Error{Code: "40001"}
+	fake.StubError("UPDATE accounts", serialization)
+
+	err := helper.WithTx(context.Background(), nil, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE accounts SET name = $1", "a")
+		return err
+	})
+	if err != serialization {
+		t.Fatalf("WithTx() error = %v, want %v", err, serialization)
+	}
+	// the first attempt and the 3 default retries
+	fake.AssertCount(t, "UPDATE accounts", 4)
+
+	fake.Reset()
+	fake.StubError("UPDATE accounts", errors.New("syntax error"))
+	_ = helper.WithTx(context.Background(), nil, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE accounts SET name = $1", "a")
+		return err
+	})
+	fake.AssertCount(t, "UPDATE accounts", 1)
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrConcurrentModification is matched by errors.Is when a versioned write finds another version of the row
+var ErrConcurrentModification = errors.New("db: concurrent modification")
+
+type (
+	// ConcurrentModificationError is returned when a versioned write affects no row because the row
+	// was changed since it was read
+	ConcurrentModificationError struct {
+		Table string
+		Key   interface{}
+		// Expected is the version given to the write, Actual the current version of the row
+		Expected int64
+		Actual   int64
+	}
+
+	// VersionOption names the columns of a VersionedTable
+	VersionOption struct {
+		// KeyColumn identifies a row, default id
+		KeyColumn string
+		// VersionColumn is incremented by every write, default version
+		VersionColumn string
+		// DeletedAtColumn is set by SoftDelete and cleared by Restore, default deleted_at
+		DeletedAtColumn string
+	}
+
+	// VersionedTable writes the rows of a table with optimistic concurrency: every write checks and increments
+	// the version column and only applies to rows which are not soft deleted (Restore excepted).
+	// A transaction carried by ctx (see ContextWithTx) is used, the helper otherwise.
+	VersionedTable struct {
+		helper DBHelper
+		table  string
+		option VersionOption
+	}
+
+	// versionExecer is implemented by DBHelper and *sql.Tx
+	versionExecer interface {
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+		QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	}
+)
+
+func (e *ConcurrentModificationError) Error() string {
+	return fmt.Sprintf("db: concurrent modification of %s %v: expected version %d, found %d", e.Table, e.Key, e.Expected, e.Actual)
+}
+
+// Is matches ErrConcurrentModification
+func (e *ConcurrentModificationError) Is(target error) bool {
+	return target == ErrConcurrentModification
+}
+
+// NewVersionedTable creates an instance, table and column names are written as given
+func NewVersionedTable(helper DBHelper, table string, option VersionOption) *VersionedTable {
+	if option.KeyColumn == "" {
+		option.KeyColumn = "id"
+	}
+	if option.VersionColumn == "" {
+		option.VersionColumn = "version"
+	}
+	if option.DeletedAtColumn == "" {
+		option.DeletedAtColumn = "deleted_at"
+	}
+	return &VersionedTable{helper: helper, table: table, option: option}
+}
+
+// Update sets values on the row identified by key when it is at version and returns the new version.
+// A *ConcurrentModificationError is returned when the row is at another version and sql.ErrNoRows
+// when it does not exist or is soft deleted.
+func (t *VersionedTable) Update(ctx context.Context, key interface{}, version int64, values map[string]interface{}) (int64, error) {
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	assignments := make([]string, 0, len(columns))
+	agruments := make([]interface{}, 0, len(columns)+2)
+	for _, column := range columns {
+		agruments = append(agruments, values[column])
+		assignments = append(assignments, column+" = "+t.helper.Dialect().Placeholder(len(agruments)))
+	}
+	return t.write(ctx, assignments, agruments, key, version, false)
+}
+
+// SoftDelete sets the deleted_at column of the row identified by key when it is at version and returns
+// the new version, the errors are those of Update
+func (t *VersionedTable) SoftDelete(ctx context.Context, key interface{}, version int64) (int64, error) {
+	assignments := []string{t.option.DeletedAtColumn + " = " + t.helper.Dialect().Placeholder(1)}
+	return t.write(ctx, assignments, []interface{}{time.Now().UTC()}, key, version, false)
+}
+
+// Restore clears the deleted_at column of the soft deleted row identified by key when it is at version and
+// returns the new version. sql.ErrNoRows is returned when the row does not exist or is not deleted.
+func (t *VersionedTable) Restore(ctx context.Context, key interface{}, version int64) (int64, error) {
+	return t.write(ctx, []string{t.option.DeletedAtColumn + " = NULL"}, nil, key, version, true)
+}
+
+func (t *VersionedTable) write(ctx context.Context, assignments []string, agruments []interface{}, key interface{},
+	version int64, deleted bool) (int64, error) {
+	dialect := t.helper.Dialect()
+	assignments = append(assignments, t.option.VersionColumn+" = "+t.option.VersionColumn+" + 1")
+	agruments = append(agruments, key, version)
+	statement := fmt.Sprintf("UPDATE %s SET %s WHERE %s = %s AND %s = %s AND %s", t.table, strings.Join(assignments, ", "),
+		t.option.KeyColumn, dialect.Placeholder(len(agruments)-1), t.option.VersionColumn, dialect.Placeholder(len(agruments)),
+		t.deletedCondition(deleted))
+
+	exec := t.execer(ctx)
+	result, err := exec.ExecContext(ctx, statement, agruments...)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected > 0 {
+		return version + 1, nil
+	}
+
+	// no row matched, tell a missing row from a newer version, the row was read on the primary just now
+	var current int64
+	err = exec.QueryRowContext(ForcePrimary(ctx), fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s AND %s", t.option.VersionColumn, t.table,
+		t.option.KeyColumn, dialect.Placeholder(1), t.deletedCondition(deleted)), key).Scan(&current)
+	if err != nil {
+		return 0, err
+	}
+	return 0, &ConcurrentModificationError{Table: t.table, Key: key, Expected: version, Actual: current}
+}
+
+func (t *VersionedTable) deletedCondition(deleted bool) string {
+	if deleted {
+		return t.option.DeletedAtColumn + " IS NOT NULL"
+	}
+	return t.option.DeletedAtColumn + " IS NULL"
+}
+
+func (t *VersionedTable) execer(ctx context.Context) versionExecer {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return t.helper
+}
@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// LivenessService is the grpc.health.v1 service name answering with the liveness report,
+	// the empty service name answers with the readiness report and a check name with that check
+	LivenessService = "liveness"
+
+	defaultWatchInterval = 5 * time.Second
+)
+
+type grpcServer struct {
+	registry      *Registry
+	watchInterval time.Duration
+}
+
+// NewGRPCServer returns a grpc.health.v1 server backed by the registry, Watch polls the checks every watchInterval (default 5s)
+func (r *Registry) NewGRPCServer(watchInterval time.Duration) healthpb.HealthServer {
+	if watchInterval <= 0 {
+		watchInterval = defaultWatchInterval
+	}
+	return &grpcServer{registry: r, watchInterval: watchInterval}
+}
+
+// RegisterGRPC registers the health server of DefaultRegistry on server
+func RegisterGRPC(server *grpc.Server) {
+	healthpb.RegisterHealthServer(server, DefaultRegistry.NewGRPCServer(0))
+}
+
+func (s *grpcServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	servingStatus, ok := s.status(ctx, req.GetService())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+	return &healthpb.HealthCheckResponse{Status: servingStatus}, nil
+}
+
+// Watch sends the status immediately and then whenever it changes, unknown services are reported as SERVICE_UNKNOWN
+func (s *grpcServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		servingStatus, ok := s.status(stream.Context(), req.GetService())
+		if !ok {
+			servingStatus = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if servingStatus != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: servingStatus}); err != nil {
+				return err
+			}
+			last = servingStatus
+		}
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *grpcServer) status(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, bool) {
+	var up bool
+	switch service {
+	case "":
+		up = s.registry.Readiness(ctx).Status == StatusUp
+	case LivenessService:
+		up = s.registry.Liveness(ctx).Status == StatusUp
+	default:
+		result, ok := s.registry.Check(ctx, service)
+		if !ok {
+			return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, false
+		}
+		up = result.Status == StatusUp
+	}
+	if up {
+		return healthpb.HealthCheckResponse_SERVING, true
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING, true
+}
@@ -0,0 +1,233 @@
+// Package health aggregates the health checks of a service into liveness and readiness reports,
+// served over the grpc.health.v1 protocol and HTTP /healthz and /readyz endpoints.
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = time.Second
+)
+
+const (
+	// StatusUp means every check passed
+	StatusUp Status = "UP"
+	// StatusDown means at least one check failed
+	StatusDown Status = "DOWN"
+)
+
+type (
+	// Status of a check or a report
+	Status string
+
+	// Checker is implemented by db.DBHelper and cache.CacheHelper
+	Checker interface {
+		HealthCheck(ctx context.Context) error
+	}
+
+	// CheckerFunc adapts a function to Checker
+	CheckerFunc func(ctx context.Context) error
+
+	// Option configures a registered check
+	Option struct {
+		// Timeout bounds a single run of the check, default 2s
+		Timeout time.Duration
+		// CacheTTL reuses the last result for this long so probes do not hammer dependencies, default 1s
+		CacheTTL time.Duration
+		// Liveness includes the check in the liveness report, by default checks only gate readiness.
+		// Only checks whose failure requires a restart belong there.
+		Liveness bool
+	}
+
+	// Result is the outcome of a single check
+	Result struct {
+		Name      string    `json:"name"`
+		Status    Status    `json:"status"`
+		Error     string    `json:"error,omitempty"`
+		Duration  string    `json:"duration"`
+		CheckedAt time.Time `json:"checked_at"`
+	}
+
+	// Report aggregates the results, it is down when any check is down
+	Report struct {
+		Status Status   `json:"status"`
+		Checks []Result `json:"checks"`
+	}
+
+	// Registry holds the registered checks
+	Registry struct {
+		mutex  sync.RWMutex
+		checks map[string]*check
+	}
+
+	check struct {
+		name    string
+		checker Checker
+		option  Option
+
+		mutex  sync.Mutex
+		result Result
+		expiry time.Time
+		// running is closed once the in-flight execution stored its result, nil when none runs
+		running chan struct{}
+	}
+)
+
+// HealthCheck calls f
+func (f CheckerFunc) HealthCheck(ctx context.Context) error {
+	return f(ctx)
+}
+
+// DefaultRegistry is used by the package level functions
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{checks: map[string]*check{}}
+}
+
+// Register adds checker under name, registering the same name again replaces it
+func (r *Registry) Register(name string, checker Checker, opt Option) {
+	if opt.Timeout <= 0 {
+		opt.Timeout = defaultTimeout
+	}
+	if opt.CacheTTL <= 0 {
+		opt.CacheTTL = defaultCacheTTL
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.checks[name] = &check{name: name, checker: checker, option: opt}
+}
+
+// Unregister removes the check registered under name
+func (r *Registry) Unregister(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.checks, name)
+}
+
+// Liveness runs the checks registered with Liveness, an empty set is up. The checks still waited for
+// when ctx is done are reported down with its error.
+func (r *Registry) Liveness(ctx context.Context) Report {
+	return r.run(ctx, func(c *check) bool {
+		return c.option.Liveness
+	})
+}
+
+// Readiness runs every registered check, like Liveness ctx bounds the wait for the results
+func (r *Registry) Readiness(ctx context.Context) Report {
+	return r.run(ctx, func(*check) bool {
+		return true
+	})
+}
+
+// Check runs the check registered under name, ok is false when there is none. The result is down
+// with the error of ctx when it is done first.
+func (r *Registry) Check(ctx context.Context, name string) (result Result, ok bool) {
+	r.mutex.RLock()
+	c, ok := r.checks[name]
+	r.mutex.RUnlock()
+	if !ok {
+		return Result{}, false
+	}
+	return c.run(ctx), true
+}
+
+// run executes the selected checks concurrently, results are sorted by name
+func (r *Registry) run(ctx context.Context, selected func(*check) bool) Report {
+	r.mutex.RLock()
+	var checks []*check
+	for _, c := range r.checks {
+		if selected(c) {
+			checks = append(checks, c)
+		}
+	}
+	r.mutex.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: make([]Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	sort.Slice(report.Checks, func(i, j int) bool {
+		return report.Checks[i].Name < report.Checks[j].Name
+	})
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// run returns the cached result or waits for the execution of the check until ctx is done, concurrent
+// callers share a single execution. The check does not run on the caller context since its result is
+// cached for every caller, a probe disconnecting early would otherwise cache the check as down.
+func (c *check) run(ctx context.Context) Result {
+	start := time.Now()
+	c.mutex.Lock()
+	if start.Before(c.expiry) {
+		defer c.mutex.Unlock()
+		return c.result
+	}
+	if c.running == nil {
+		c.running = make(chan struct{})
+		go c.execute(c.running)
+	}
+	running := c.running
+	c.mutex.Unlock()
+
+	select {
+	case <-running:
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		return c.result
+	case <-ctx.Done():
+		return Result{Name: c.name, Status: StatusDown, Error: ctx.Err().Error(), Duration: time.Since(start).String(),
+			CheckedAt: start}
+	}
+}
+
+// execute runs the check, caches its result and closes running
+func (c *check) execute(running chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.option.Timeout)
+	defer cancel()
+	start := time.Now()
+	err := c.checker.HealthCheck(ctx)
+
+	result := Result{Name: c.name, Status: StatusUp, Duration: time.Since(start).String(), CheckedAt: start}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	c.mutex.Lock()
+	c.result, c.expiry, c.running = result, time.Now().Add(c.option.CacheTTL), nil
+	c.mutex.Unlock()
+	close(running)
+}
+
+// Register adds checker to DefaultRegistry
+func Register(name string, checker Checker, opt Option) {
+	DefaultRegistry.Register(name, checker, opt)
+}
+
+// Liveness runs the liveness checks of DefaultRegistry
+func Liveness(ctx context.Context) Report {
+	return DefaultRegistry.Liveness(ctx)
+}
+
+// Readiness runs every check of DefaultRegistry
+func Readiness(ctx context.Context) Report {
+	return DefaultRegistry.Readiness(ctx)
+}
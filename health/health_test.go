@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckHonorsContext(t *testing.T) {
+	release := make(chan struct{})
+	var calls int32
+	registry := NewRegistry()
+	registry.Register("slow", CheckerFunc(func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return nil
+	}), Option{CacheTTL: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if report := registry.Readiness(ctx); report.Status != StatusDown || report.Checks[0].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("Readiness() = %+v, want down with the context error", report)
+	}
+
+	// the execution keeps running for the next callers and its result is cached
+	close(release)
+	if result, ok := registry.Check(context.Background(), "slow"); !ok || result.Status != StatusUp {
+		t.Errorf("Check() = %+v, %v, want up", result, ok)
+	}
+	if result, _ := registry.Check(context.Background(), "slow"); result.Status != StatusUp {
+		t.Errorf("Check() = %+v, want the cached result", result)
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Errorf("check ran %d times, want 1", calls)
+	}
+}
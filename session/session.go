@@ -0,0 +1,314 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-core/cache"
+)
+
+const (
+	fieldUserID     = "_uid"
+	fieldCreatedAt  = "_created"
+	fieldAccessedAt = "_accessed"
+	dataFieldPrefix = "d:"
+
+	sessionIDBytes = 32
+)
+
+var (
+	// ErrSessionNotFound is returned when the session does not exist or has expired
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrInvalidSessionID is returned when the session id was not generated by the store
+	ErrInvalidSessionID = errors.New("invalid session id")
+)
+
+type (
+	// Session represents a user session
+	Session struct {
+		ID             string
+		UserID         string
+		Data           map[string]string
+		CreatedAt      time.Time
+		LastAccessedAt time.Time
+	}
+
+	// Option represents session store option
+	Option struct {
+		// KeyPrefix prefixes every key written by the store, default "session"
+		KeyPrefix string
+		// IdleTimeout is the sliding expiration refreshed by Touch, default 30 minutes
+		IdleTimeout time.Duration
+		// AbsoluteTimeout caps the session lifetime regardless of activity, zero means unlimited
+		AbsoluteTimeout time.Duration
+		// MaxSessionsPerUser destroys the oldest sessions of a user above this limit, zero means unlimited
+		MaxSessionsPerUser int
+	}
+
+	// Store manages sessions stored in cache hashes
+	Store interface {
+		Create(ctx context.Context, userID string, data map[string]string) (*Session, error)
+		Load(ctx context.Context, sessionID string) (*Session, error)
+		// Touch loads the session and extends its idle expiration
+		Touch(ctx context.Context, sessionID string) (*Session, error)
+		SetData(ctx context.Context, sessionID string, data map[string]string) error
+		Destroy(ctx context.Context, sessionID string) error
+		// DestroyAll destroys every session of the user, i.e. log out everywhere
+		DestroyAll(ctx context.Context, userID string) error
+		List(ctx context.Context, userID string) ([]*Session, error)
+	}
+
+	cacheStore struct {
+		helper cache.CacheHelper
+		option Option
+	}
+)
+
+// NewStore creates an instance
+func NewStore(helper cache.CacheHelper, option Option) Store {
+	if option.KeyPrefix == "" {
+		option.KeyPrefix = "session"
+	}
+	if option.IdleTimeout <= 0 {
+		option.IdleTimeout = 30 * time.Minute
+	}
+	return &cacheStore{
+		helper: helper,
+		option: option,
+	}
+}
+
+// NewSessionID returns a random URL safe session id with 256 bits of entropy
+func NewSessionID() (string, error) {
+	buffer := make([]byte, sessionIDBytes)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+func validSessionID(sessionID string) bool {
+	decoded, err := base64.RawURLEncoding.DecodeString(sessionID)
+	return err == nil && len(decoded) == sessionIDBytes
+}
+
+func (s *cacheStore) sessionKey(sessionID string) string {
+	return s.option.KeyPrefix + ":" + sessionID
+}
+
+func (s *cacheStore) userKey(userID string) string {
+	return s.option.KeyPrefix + ":user:" + userID
+}
+
+// indexExpiration keeps the user index alive as long as its longest possible session
+func (s *cacheStore) indexExpiration() time.Duration {
+	if s.option.AbsoluteTimeout > s.option.IdleTimeout {
+		return s.option.AbsoluteTimeout
+	}
+	return s.option.IdleTimeout
+}
+
+// expiration returns the idle timeout bounded by the remaining absolute lifetime
+func (s *cacheStore) expiration(createdAt, now time.Time) time.Duration {
+	expiration := s.option.IdleTimeout
+	if s.option.AbsoluteTimeout > 0 {
+		if remaining := createdAt.Add(s.option.AbsoluteTimeout).Sub(now); remaining < expiration {
+			expiration = remaining
+		}
+	}
+	return expiration
+}
+
+func (s *cacheStore) Create(ctx context.Context, userID string, data map[string]string) (*Session, error) {
+	sessionID, err := NewSessionID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &Session{
+		ID:             sessionID,
+		UserID:         userID,
+		Data:           data,
+		CreatedAt:      now,
+		LastAccessedAt: now,
+	}
+
+	fields := make(map[string]interface{}, len(data)+3)
+	for key, value := range data {
+		fields[dataFieldPrefix+key] = value
+	}
+	fields[fieldUserID] = userID
+	fields[fieldCreatedAt] = formatTime(now)
+	fields[fieldAccessedAt] = formatTime(now)
+	if _, err := s.helper.HMSet(ctx, s.sessionKey(sessionID), fields, s.option.IdleTimeout); err != nil {
+		return nil, err
+	}
+
+	index := map[string]interface{}{sessionID: formatTime(now)}
+	if _, err := s.helper.HMSet(ctx, s.userKey(userID), index, s.indexExpiration()); err != nil {
+		return nil, err
+	}
+	if s.option.MaxSessionsPerUser > 0 {
+		if err := s.enforceLimit(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// enforceLimit destroys the oldest sessions above MaxSessionsPerUser
+func (s *cacheStore) enforceLimit(ctx context.Context, userID string) error {
+	sessions, err := s.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for len(sessions) > s.option.MaxSessionsPerUser {
+		if err := s.destroy(ctx, sessions[0].ID, userID); err != nil {
+			return err
+		}
+		sessions = sessions[1:]
+	}
+	return nil
+}
+
+func (s *cacheStore) Load(ctx context.Context, sessionID string) (*Session, error) {
+	if !validSessionID(sessionID) {
+		return nil, ErrInvalidSessionID
+	}
+	values, err := s.helper.HGetAll(ctx, s.sessionKey(sessionID), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, ErrSessionNotFound
+	}
+
+	session := &Session{
+		ID:             sessionID,
+		UserID:         values[fieldUserID],
+		Data:           make(map[string]string, len(values)),
+		CreatedAt:      parseTime(values[fieldCreatedAt]),
+		LastAccessedAt: parseTime(values[fieldAccessedAt]),
+	}
+	for key, value := range values {
+		if strings.HasPrefix(key, dataFieldPrefix) {
+			session.Data[strings.TrimPrefix(key, dataFieldPrefix)] = value
+		}
+	}
+	if s.option.AbsoluteTimeout > 0 && time.Since(session.CreatedAt) >= s.option.AbsoluteTimeout {
+		_ = s.destroy(ctx, sessionID, session.UserID)
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+func (s *cacheStore) Touch(ctx context.Context, sessionID string) (*Session, error) {
+	session, err := s.Load(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	fields := map[string]interface{}{fieldAccessedAt: formatTime(now)}
+	if _, err := s.helper.HMSet(ctx, s.sessionKey(sessionID), fields, s.expiration(session.CreatedAt, now)); err != nil {
+		return nil, err
+	}
+	if err := s.helper.Expire(ctx, s.userKey(session.UserID), s.indexExpiration()); err != nil {
+		return nil, err
+	}
+	session.LastAccessedAt = now
+	return session, nil
+}
+
+func (s *cacheStore) SetData(ctx context.Context, sessionID string, data map[string]string) error {
+	session, err := s.Load(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		fields[dataFieldPrefix+key] = value
+	}
+	_, err = s.helper.HMSet(ctx, s.sessionKey(sessionID), fields, s.expiration(session.CreatedAt, time.Now()))
+	return err
+}
+
+func (s *cacheStore) Destroy(ctx context.Context, sessionID string) error {
+	if !validSessionID(sessionID) {
+		return ErrInvalidSessionID
+	}
+	userID, err := s.helper.HGet(ctx, s.sessionKey(sessionID), fieldUserID)
+	if err != nil {
+		return err
+	}
+	if userID == "" {
+		return nil
+	}
+	return s.destroy(ctx, sessionID, userID)
+}
+
+func (s *cacheStore) destroy(ctx context.Context, sessionID, userID string) error {
+	if err := s.helper.Del(ctx, s.sessionKey(sessionID)); err != nil {
+		return err
+	}
+	_, err := s.helper.HDel(ctx, s.userKey(userID), sessionID)
+	return err
+}
+
+func (s *cacheStore) DestroyAll(ctx context.Context, userID string) error {
+	index, err := s.helper.HGetAll(ctx, s.userKey(userID), nil)
+	if err != nil {
+		return err
+	}
+	for sessionID := range index {
+		if err := s.helper.Del(ctx, s.sessionKey(sessionID)); err != nil {
+			return err
+		}
+	}
+	return s.helper.Del(ctx, s.userKey(userID))
+}
+
+// List returns the live sessions of the user sorted from oldest to newest,
+// index entries of expired sessions are pruned on the way
+func (s *cacheStore) List(ctx context.Context, userID string) ([]*Session, error) {
+	index, err := s.helper.HGetAll(ctx, s.userKey(userID), nil)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]*Session, 0, len(index))
+	var expired []string
+	for sessionID := range index {
+		session, err := s.Load(ctx, sessionID)
+		if err != nil {
+			if errors.Is(err, ErrSessionNotFound) || errors.Is(err, ErrInvalidSessionID) {
+				expired = append(expired, sessionID)
+				continue
+			}
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	if len(expired) > 0 {
+		if _, err := s.helper.HDel(ctx, s.userKey(userID), expired...); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+func formatTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func parseTime(value string) time.Time {
+	nano, _ := strconv.ParseInt(value, 10, 64)
+	return time.Unix(0, nano)
+}